@@ -0,0 +1,191 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Type *BufferReporter is an implementation of the Reporter interface
+// that may be shared by multiple goroutines.
+//
+// It records calls in the same way as StubReporter, and like StubReporter,
+// its FailNow, Fatal, and Fatalf methods return. In addition, it provides
+// a channel that is closed when the BufferReporter is first marked failed,
+// so that a goroutine coordinating a test can stop other work promptly.
+//
+// The zero value is ready to use. A BufferReporter must not be copied after first use.
+type BufferReporter struct {
+	mu                      sync.Mutex
+	sr                      StubReporter
+	failed, killed, skipped atomic.Bool
+	failedChan              chan struct{}
+}
+
+// Helper marks a function as a helper function.
+//
+// The BufferReporter version of Helper does nothing.
+func (br *BufferReporter) Helper() {}
+
+// Fail marks a test as failed, and closes the channel returned by FailedChan.
+func (br *BufferReporter) Fail() {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	if !br.failed.Swap(true) && br.failedChan != nil {
+		close(br.failedChan)
+	}
+}
+
+// Failed returns whether the test was marked failed.
+func (br *BufferReporter) Failed() bool {
+	return br.failed.Load()
+}
+
+// FailedChan returns a channel that is closed when the test is marked failed.
+//
+// The same channel is returned on every call.
+func (br *BufferReporter) FailedChan() <-chan struct{} {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	if br.failedChan == nil {
+		br.failedChan = make(chan struct{})
+		if br.failed.Load() {
+			close(br.failedChan)
+		}
+	}
+	return br.failedChan
+}
+
+// FailNow marks a test as failed.
+//
+// As with StubReporter, FailNow, Fatal, and Fatalf return to their callers.
+func (br *BufferReporter) FailNow() {
+	br.Fail()
+	br.killed.Store(true)
+}
+
+// Killed returns whether FailNow was called.
+func (br *BufferReporter) Killed() bool {
+	return br.killed.Load()
+}
+
+// Log formats its arguments as if by fmt.Println and records the resulting text.
+//
+// See StubReporter.Log for a note on arguments ending with a newline.
+func (br *BufferReporter) Log(args ...any) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	br.sr.Log(args...)
+}
+
+// Logf formats its arguments as if by fmt.Printf and records the resulting text.
+func (br *BufferReporter) Logf(format string, args ...any) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	br.sr.Logf(format, args...)
+}
+
+// Logged returns the text recorded by Log and Logf.
+func (br *BufferReporter) Logged() string {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	return br.sr.Logged()
+}
+
+// Error calls Log and Fail.
+func (br *BufferReporter) Error(args ...any) {
+	br.Log(args...)
+	br.Fail()
+}
+
+// Errorf calls Logf and Fail.
+func (br *BufferReporter) Errorf(format string, args ...any) {
+	br.Logf(format, args...)
+	br.Fail()
+}
+
+// Fatal calls Log and FailNow.
+func (br *BufferReporter) Fatal(args ...any) {
+	br.Log(args...)
+	br.FailNow()
+}
+
+// Fatalf calls Logf and FailNow.
+func (br *BufferReporter) Fatalf(format string, args ...any) {
+	br.Logf(format, args...)
+	br.FailNow()
+}
+
+// SkipNow marks a test as skipped.
+//
+// As with StubReporter, SkipNow, Skip, and Skipf return to their callers.
+func (br *BufferReporter) SkipNow() {
+	br.skipped.Store(true)
+}
+
+// Skipped returns whether SkipNow was called.
+func (br *BufferReporter) Skipped() bool {
+	return br.skipped.Load()
+}
+
+// Skip calls Log and SkipNow.
+func (br *BufferReporter) Skip(args ...any) {
+	br.Log(args...)
+	br.SkipNow()
+}
+
+// Skipf calls Logf and SkipNow.
+func (br *BufferReporter) Skipf(format string, args ...any) {
+	br.Logf(format, args...)
+	br.SkipNow()
+}
+
+// Cleanup registers a function to be called by RunCleanup.
+func (br *BufferReporter) Cleanup(f func()) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	br.sr.Cleanup(f)
+}
+
+// TempDir creates a new temporary directory and returns its name.
+//
+// The directory and its contents are removed by RunCleanup.
+// If the directory cannot be created, TempDir panics.
+func (br *BufferReporter) TempDir() string {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	return br.sr.TempDir()
+}
+
+// Name returns the name set by SetName, or "" if SetName has not been called.
+func (br *BufferReporter) Name() string {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	return br.sr.Name()
+}
+
+// SetName sets the name returned by Name.
+func (br *BufferReporter) SetName(name string) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	br.sr.SetName(name)
+}
+
+// RunCleanup calls the functions registered by Cleanup, most recently registered first,
+// and forgets them.
+func (br *BufferReporter) RunCleanup() {
+	for {
+		br.mu.Lock()
+		last := len(br.sr.cleanups) - 1
+		if last < 0 {
+			br.mu.Unlock()
+			return
+		}
+		f := br.sr.cleanups[last]
+		br.sr.cleanups = br.sr.cleanups[:last]
+		br.mu.Unlock()
+		f()
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+var _ Reporter = &BufferReporter{}
+
+func TestBufferReporter(t *testing.T) {
+	var br BufferReporter
+	br.Helper()
+	br.Log("one")
+	br.Logf("%s", "two")
+	Expect(t, false, br.Failed())
+	Expect(t, false, br.Killed())
+	Expect(t, "one\ntwo\n", br.Logged())
+
+	br.Error("three")
+	Expect(t, true, br.Failed())
+	Expect(t, false, br.Killed())
+	br.Fatalf("%d", 4)
+	Expect(t, true, br.Killed())
+	Expect(t, "one\ntwo\nthree\n4\n", br.Logged())
+
+	var br2 BufferReporter
+	br2.Errorf("x")
+	br2.Fatal("y")
+	Expect(t, true, br2.Failed())
+	Expect(t, true, br2.Killed())
+	Expect(t, "x\ny\n", br2.Logged())
+
+	var br3 BufferReporter
+	br3.FailNow()
+	Expect(t, true, br3.Failed())
+	Expect(t, true, br3.Killed())
+	Expect(t, "", br3.Logged())
+}
+
+func TestBufferSkip(t *testing.T) {
+	var br BufferReporter
+	Expect(t, false, br.Skipped())
+	br.Skip("one")
+	Expect(t, true, br.Skipped())
+	Expect(t, false, br.Failed())
+
+	var br2 BufferReporter
+	br2.Skipf("%d", 2)
+	Expect(t, true, br2.Skipped())
+	br2.SkipNow()
+	Expect(t, true, br2.Skipped())
+	Expect(t, "one\n", br.Logged())
+	Expect(t, "2\n", br2.Logged())
+}
+
+func TestBufferCleanup(t *testing.T) {
+	var br BufferReporter
+	var order []int
+	br.Cleanup(func() { order = append(order, 1) })
+	br.Cleanup(func() {
+		order = append(order, 2)
+		br.Cleanup(func() { order = append(order, 3) })
+	})
+	br.RunCleanup()
+	ExpectSlice(t, []int{2, 3, 1}, order)
+}
+
+func TestBufferName(t *testing.T) {
+	var br BufferReporter
+	Expect(t, "", br.Name())
+	br.SetName("shared")
+	Expect(t, "shared", br.Name())
+}
+
+func TestBufferFailedChan(t *testing.T) {
+	var br BufferReporter
+	ch := br.FailedChan()
+	Require(t, ch == br.FailedChan())
+	select {
+	case <-ch:
+		t.Fatal("FailedChan closed before failure")
+	default:
+	}
+	br.Fail()
+	<-ch
+	br.Fail()
+	<-br.FailedChan()
+
+	var br2 BufferReporter
+	br2.Fatal("late")
+	<-br2.FailedChan()
+}
+
+func TestBufferConcurrent(t *testing.T) {
+	var br BufferReporter
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-br.FailedChan()
+			br.Log("stopped")
+		}()
+	}
+	br.Error("failure")
+	wg.Wait()
+	Expect(t, 11, strings.Count(br.Logged(), "\n"))
+	Require(t, strings.HasPrefix(br.Logged(), "failure\n"))
+}
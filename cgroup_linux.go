@@ -0,0 +1,170 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Constant cpuPeriod is the period, in microseconds, used for cgroup CPU limits.
+const cpuPeriod = 100000
+
+// Type cgroup is a cgroup v2 directory created to limit the resources of a single run of a command.
+type cgroup struct {
+	dir string
+	fd  *os.File
+}
+
+// Function newCgroup creates a cgroup with the given limits, beside the cgroup of the test
+// process, or below it if that is a root cgroup. A zero limit leaves that resource unlimited.
+func newCgroup(memBytes int64, cpuQuota float64) (*cgroup, error) {
+	var needed []string
+	if memBytes > 0 {
+		needed = append(needed, "memory")
+	}
+	if cpuQuota > 0 {
+		needed = append(needed, "cpu")
+	}
+	parent, e := cgroupParent(needed)
+	if e != nil {
+		return nil, e
+	}
+
+	dir, e := os.MkdirTemp(parent, "gotest-")
+	if e != nil {
+		return nil, e
+	}
+	cg := &cgroup{dir: dir}
+	if memBytes > 0 {
+		if e = cg.write("memory.max", fmt.Sprint(memBytes)); e == nil {
+			// Without swap, exceeding the limit reliably invokes the OOM killer.
+			// Not all systems have swap accounting, so ignore any error here.
+			cg.write("memory.swap.max", "0")
+		}
+	}
+	if e == nil && cpuQuota > 0 {
+		e = cg.write("cpu.max", fmt.Sprintf("%d %d", max(1000, int64(cpuQuota*cpuPeriod)), cpuPeriod))
+	}
+	if e == nil {
+		cg.fd, e = os.Open(dir)
+	}
+	if e != nil {
+		cg.remove()
+		return nil, e
+	}
+	return cg, nil
+}
+
+// Function cgroupParent returns the cgroup in which to create the cgroups for runs,
+// after checking that the needed controllers are enabled for its children.
+//
+// Under the cgroup v2 "no internal processes" rule, a cgroup other than a root can not
+// both contain processes and enable controllers for its children. So, unless the test
+// process is in a root cgroup, the new cgroups are created beside it, in its parent.
+// The cgroup hierarchy is never changed other than by creating and removing those cgroups.
+func cgroupParent(needed []string) (string, error) {
+	mount, path, e := currentCgroup()
+	if e != nil {
+		return "", e
+	}
+	dir := filepath.Join(mount, path)
+	if path != "/" {
+		dir = filepath.Dir(dir)
+	}
+	return dir, checkControllers(dir, needed)
+}
+
+// Function checkControllers checks that the needed controllers are enabled
+// for the children of the cgroup dir.
+func checkControllers(dir string, needed []string) error {
+	control := filepath.Join(dir, "cgroup.subtree_control")
+	enabled, e := os.ReadFile(control)
+	if e != nil {
+		return e
+	}
+	for _, controller := range needed {
+		if !slices.Contains(strings.Fields(string(enabled)), controller) {
+			return fmt.Errorf("%s controller is not enabled in %s; CgroupLimit needs it enabled there,"+
+				" and write access to %s, as described in its documentation", controller, control, dir)
+		}
+	}
+	return nil
+}
+
+// Function currentCgroup returns the mount point of the cgroup v2 file system,
+// and the path within it of the cgroup holding the test process.
+func currentCgroup() (mount, path string, err error) {
+	data, e := os.ReadFile("/proc/self/cgroup")
+	if e != nil {
+		return "", "", e
+	}
+	found := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if p, ok := strings.CutPrefix(line, "0::"); ok {
+			path, found = p, true
+		}
+	}
+	if !found {
+		return "", "", errors.New("no cgroup v2 hierarchy found in /proc/self/cgroup")
+	}
+
+	mounts, e := os.Open("/proc/self/mountinfo")
+	if e != nil {
+		return "", "", e
+	}
+	defer mounts.Close()
+	scanner := bufio.NewScanner(mounts)
+	for scanner.Scan() {
+		// The fields are described in proc(5); the mount point is the fifth,
+		// and the file system type follows the separator "-".
+		fields := strings.Fields(scanner.Text())
+		sep := slices.Index(fields, "-")
+		if len(fields) >= 5 && sep >= 0 && sep+1 < len(fields) && fields[sep+1] == "cgroup2" {
+			return fields[4], path, nil
+		}
+	}
+	if e := scanner.Err(); e != nil {
+		return "", "", e
+	}
+	return "", "", errors.New("no cgroup2 file system is mounted")
+}
+
+// Method write writes value to the control file name of the cgroup.
+func (cg *cgroup) write(name, value string) error {
+	return os.WriteFile(filepath.Join(cg.dir, name), []byte(value), 0)
+}
+
+// Method attach arranges for cmd to be started in the cgroup.
+func (cg *cgroup) attach(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(cg.fd.Fd())
+}
+
+// Method remove kills any processes left in the cgroup, and then removes it.
+func (cg *cgroup) remove() {
+	if cg.fd != nil {
+		cg.fd.Close()
+	}
+	// cgroup.kill is not available before Linux 5.14; then stray processes may delay removal.
+	cg.write("cgroup.kill", "1")
+	for i := 0; i < 50; i++ {
+		e := os.Remove(cg.dir)
+		if e == nil || errors.Is(e, os.ErrNotExist) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCmdCgroupLimit(t *testing.T) {
+	c := Command("/bin/sh", "-c", "echo ok")
+	c.CgroupLimit(64<<20, 0.5)
+	c.WantStdout("ok\n")
+	var st StubReporter
+	c.Run(&st, "")
+	if st.Failed() {
+		// This system does not allow the test to create cgroups; check the failure is explained.
+		Expect(t, true, st.Killed())
+		Require(t, strings.HasPrefix(st.Logged(), "gotest.Cmd: cgroup setup failed: "))
+		t.Skip("cgroups not available:", strings.TrimSpace(st.Logged()))
+	}
+
+	c = Command("/bin/sh", "-c", `x=$(head -c 100000000 /dev/zero | tr '\0' a); echo done`)
+	c.CgroupLimit(32<<20, 0)
+	c.WantExitCategory(Signal)
+	c.Run(t, "")
+
+	c.CgroupLimit(0, 0)
+	c.WantStdout("done\n")
+	c.WantExitCategory(Success)
+	c.Run(t, "")
+}
+
+// Function fakeCgroup creates a directory imitating a cgroup, with the given control files.
+func fakeCgroup(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if e := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); e != nil {
+			t.Fatal(e)
+		}
+	}
+	return dir
+}
+
+func TestCheckControllers(t *testing.T) {
+	dir := fakeCgroup(t, map[string]string{"cgroup.subtree_control": "cpu memory\n"})
+	Require(t, checkControllers(dir, []string{"memory", "cpu"}) == nil)
+	Require(t, checkControllers(dir, nil) == nil)
+
+	dir = fakeCgroup(t, map[string]string{"cgroup.subtree_control": "cpu\n"})
+	e := checkControllers(dir, []string{"cpu", "memory"})
+	Require(t, e != nil)
+	Require(t, strings.HasPrefix(e.Error(), "memory controller is not enabled in "+filepath.Join(dir, "cgroup.subtree_control")+"; "))
+	Expect(t, "cpu\n", readFile(t, filepath.Join(dir, "cgroup.subtree_control")))
+}
@@ -0,0 +1,23 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+//go:build !linux
+
+package gotest
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// Type cgroup is not supported on this system.
+type cgroup struct{}
+
+// Function newCgroup always fails, as cgroups are available only on Linux.
+func newCgroup(memBytes int64, cpuQuota float64) (*cgroup, error) {
+	return nil, errors.New("cgroup limits are supported only on Linux")
+}
+
+func (cg *cgroup) attach(cmd *exec.Cmd) {}
+
+func (cg *cgroup) remove() {}
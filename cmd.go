@@ -4,8 +4,21 @@
 package gotest
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // A Cmd runs an external command inside a test case
@@ -14,8 +27,126 @@ type Cmd struct {
 	name               string
 	args               []string
 	dir                string
-	checkOut, checkErr func(actual string) bool
-	checkCode          func(actual int) bool
+	checkOut, checkErr func(t Reporter, actual string) bool
+	checkCode          func(t Reporter, actual int) bool
+	outBuf, errBuf     *bytes.Buffer
+	minChunks          int
+	checkFds           bool
+	throttle           int
+	generated          int64         // If positive, the size of the generated input to use.
+	within             time.Duration // If positive, the time allowed for each run.
+	input              *string       // The input for the current run, if known, for checks that compare to it.
+	checkFiles         bool
+	wantFiles          []string
+	category           ExitCategory
+	cleanSuccess       bool
+	checkLines         bool
+	combinedLines      int
+	timeout            time.Duration // If positive, the time allowed for each run before it is killed.
+	env                []string      // Variables set by Setenv, each of the form "key=value".
+	clearEnv           bool          // Whether the command's environment starts empty, rather than inherited.
+	verbatim           bool          // Whether to pass the command line to Windows without quoting; see ShellCommand.
+	helpFlag           string        // The flag added by RunUsage.
+	helpCode           int           // The exit code expected by RunUsage.
+	memLimit           int64         // If positive, the memory limit set by CgroupLimit.
+	cpuQuota           float64       // If positive, the CPU limit set by CgroupLimit.
+	checkCombined      func(t Reporter, actual string) bool
+	trimSpace          bool
+	lastCode           int  // The exit code of the most recent run, or -1.
+	outDiffed          bool // Whether the most recent run reported differences in the output.
+}
+
+// A RunOption adjusts a single run of a command by RunWith.
+type RunOption func(*runConfig)
+
+// Type runConfig holds the settings for a single run of a command.
+type runConfig struct {
+	ctx      context.Context
+	input    string
+	reader   io.Reader // If not nil, the source of the input, in place of input.
+	file     string    // If not empty, the name of the file reader reads from.
+	env      []string
+	dir      string
+	combined bool // Whether to capture the output and error output together.
+}
+
+// WithInput sets the content passed to the command as its stdin.
+// Without WithInput, the command receives no input.
+func WithInput(input string) RunOption {
+	return func(rc *runConfig) {
+		rc.input = input
+	}
+}
+
+// WithEnv adds variables, each of the form "key=value", to the command's environment.
+//
+// The command inherits the environment of the test process, with these variables
+// added. If a key is repeated, the last value for it is used.
+// Multiple WithEnv options accumulate.
+func WithEnv(env ...string) RunOption {
+	return func(rc *runConfig) {
+		rc.env = append(rc.env, env...)
+	}
+}
+
+// WithDir sets the working directory for the command, overriding the directory set by Chdir.
+func WithDir(path string) RunOption {
+	return func(rc *runConfig) {
+		rc.dir = path
+	}
+}
+
+// Type result holds the results of running a command.
+type result struct {
+	out, err *bytes.Buffer
+	code     int
+	chunks   int // The number of separate chunks in which the output arrived, if counted.
+	elapsed  time.Duration
+	signaled bool   // Whether the command was terminated by a signal.
+	signal   string // A description of the signal, if known.
+	fed      int64  // The number of bytes of generated input passed to the command.
+	timedOut bool   // Whether the command was killed for taking too long.
+	canceled bool   // Whether the command was killed because its context was done.
+}
+
+// Method category returns the ExitCategory describing how the command finished.
+func (r *result) category() ExitCategory {
+	switch {
+	case r.timedOut:
+		return Timeout
+	case r.signaled:
+		return Signal
+	case r.code == 0:
+		return Success
+	default:
+		return Failure
+	}
+}
+
+// An ExitCategory describes, broadly, how a command finished.
+type ExitCategory int
+
+const (
+	Success ExitCategory = iota + 1 // The command exited with code 0.
+	Failure                         // The command exited with a non-0 code.
+	Signal                          // The command was terminated by a signal.
+	Timeout                         // The command was killed after running longer than allowed by Timeout.
+)
+
+// String returns the name of the category, such as "Success".
+func (ec ExitCategory) String() string {
+	switch ec {
+	case Success:
+		return "Success"
+	case Failure:
+		return "Failure"
+	case Signal:
+		return "Signal"
+	case Timeout:
+		return "Timeout"
+	default:
+		return fmt.Sprintf("ExitCategory(%d)", int(ec))
+	}
 }
 
 // Command creates a Cmd object to run a specific command once.
@@ -31,9 +162,93 @@ func Command(name string, args ...string) *Cmd {
 	var cmd Cmd
 	cmd.name = name
 	cmd.args = args
+	cmd.helpFlag = "--help"
+	cmd.lastCode = -1
 	return &cmd
 }
 
+// Shell creates a Cmd object to run a script with /bin/sh.
+//
+// Use Shell when the script is written for the POSIX shell and the test runs only
+// on systems that have one; use ShellCommand when the test must also run on Windows.
+//
+// If the shell supports it, the pipefail option is set before the script runs,
+// so that the exit code of a pipeline is that of the last command in it that failed,
+// rather than that of the final command. Without pipefail, a failure early in a
+// pipeline can easily go unnoticed. Note that some shells, such as older versions
+// of dash, do not support pipefail; on those the script runs without it.
+func Shell(script string) *Cmd {
+	return Command("/bin/sh", "-c", pipefailPrefix+script)
+}
+
+// ShellCommand creates a Cmd object to run a script with the usual shell for the platform:
+// on Windows, "cmd /c", and elsewhere, the same as Shell.
+//
+// Windows has no /bin/sh, so Shell can not be used there. ShellCommand allows tests
+// to run on every platform, provided the script is valid for both shells, as simple
+// commands such as "echo hello" or "exit 3" are; otherwise the script can be chosen
+// according to runtime.GOOS. WantStdoutForOS may help with output that differs
+// between platforms, such as the line endings written by cmd.
+//
+// On Windows, cmd receives the script exactly as written, rather than quoted as
+// a single argument, since cmd does not follow the usual rules for unquoting.
+func ShellCommand(script string) *Cmd {
+	if runtime.GOOS == "windows" {
+		c := Command("cmd", "/c", script)
+		c.verbatim = true
+		return c
+	}
+	return Shell(script)
+}
+
+// EnvEchoCommand creates a Cmd object that prints the value of the environment variable key.
+//
+// The value is printed with no trailing newline; if the variable is not set, nothing
+// is printed. This is useful for testing that environment variables are passed
+// to commands as intended. The key must consist of ASCII letters, digits, and
+// underscores, and not begin with a digit; otherwise EnvEchoCommand panics.
+func EnvEchoCommand(key string) *Cmd {
+	if !validEnvKey(key) {
+		panic("gotest.EnvEchoCommand: invalid environment variable name " + strconv.Quote(key))
+	}
+	return Command("/bin/sh", "-c", `printf '%s' "$`+key+`"`)
+}
+
+// Function validEnvKey reports whether key is a valid name for a shell variable.
+func validEnvKey(key string) bool {
+	for i, r := range key {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return key != ""
+}
+
+// Constant pipefailPrefix is prepended to scripts run by Shell;
+// it sets the pipefail option if the shell supports it.
+const pipefailPrefix = "(set -o pipefail) 2>/dev/null && set -o pipefail\n"
+
+// RequireCommand looks for the named command as exec.LookPath does,
+// and returns the absolute path of the executable, suitable for passing to Command.
+//
+// If the command cannot be found, the running test is skipped, since a missing
+// optional tool usually should not cause a test suite to fail.
+func RequireCommand(t Reporter, name string) string {
+	t.Helper()
+	path, e := exec.LookPath(name)
+	if e == nil {
+		path, e = filepath.Abs(path)
+	}
+	if e != nil {
+		t.Skipf("command %s not available: %v", name, e)
+		return "" // In case t.Skip has been overridden to not terminate the test case.
+	}
+	return path
+}
+
 // CheckStdout sets the function used to check the command's output.
 //
 // The check function will be passed the output produced by the command,
@@ -42,7 +257,7 @@ func Command(name string, args ...string) *Cmd {
 // CheckStdout(nil), the default, is equivalent to
 // CheckStdout(func (actual string) bool { return actual == "" }).
 func (c *Cmd) CheckStdout(check func(actual string) bool) {
-	c.checkOut = check
+	c.checkOut = ignoreReporter(check)
 }
 
 // CheckStderr sets the function used to check the error output produced by the command.
@@ -53,7 +268,7 @@ func (c *Cmd) CheckStdout(check func(actual string) bool) {
 // CheckStderr(nil), the default, is equivalent to
 // CheckStderr(func (actual string) bool { return actual == "" }).
 func (c *Cmd) CheckStderr(check func(actual string) bool) {
-	c.checkErr = check
+	c.setCheckErr(ignoreReporter(check))
 }
 
 // CheckCode sets the function used to check the command's exit code.
@@ -67,28 +282,806 @@ func (c *Cmd) CheckStderr(check func(actual string) bool) {
 // expected to be 0 if the command produced no error output, and non-0
 // otherwise.
 func (c *Cmd) CheckCode(check func(actual int) bool) {
+	c.setCheckCode(ignoreReporter(check))
+}
+
+// CheckStdoutReport sets the function used to check the command's output, as CheckStdout
+// does, but the function also receives the Reporter for the current run, through which
+// it may explain its verdict; for example, with t.Errorf("line %d is malformed", n).
+//
+// CheckStdoutReport(nil) is equivalent to CheckStdout(nil).
+func (c *Cmd) CheckStdoutReport(check func(t Reporter, actual string) bool) {
+	c.checkOut = check
+}
+
+// CheckStderrReport sets the function used to check the command's error output,
+// as CheckStderr does, but the function also receives the Reporter for the current run.
+//
+// CheckStderrReport(nil) is equivalent to CheckStderr(nil).
+func (c *Cmd) CheckStderrReport(check func(t Reporter, actual string) bool) {
+	c.setCheckErr(check)
+}
+
+// CheckCodeReport sets the function used to check the command's exit code,
+// as CheckCode does, but the function also receives the Reporter for the current run.
+//
+// CheckCodeReport(nil) is equivalent to CheckCode(nil).
+func (c *Cmd) CheckCodeReport(check func(t Reporter, actual int) bool) {
+	c.setCheckCode(check)
+}
+
+// Method setCheckErr sets the check on the command's error output,
+// replacing any check made by WantCleanSuccess.
+func (c *Cmd) setCheckErr(check func(t Reporter, actual string) bool) {
+	c.checkErr = check
+	c.cleanSuccess = false
+}
+
+// Method setCheckCode sets the check on the command's exit code,
+// replacing any check made by WantExitCategory or WantCleanSuccess.
+func (c *Cmd) setCheckCode(check func(t Reporter, actual int) bool) {
 	c.checkCode = check
+	c.category = 0
+	c.cleanSuccess = false
+}
+
+// Function ignoreReporter adapts a check function to the form stored in a Cmd.
+// If check is nil, the result is also nil.
+func ignoreReporter[T any](check func(actual T) bool) func(Reporter, T) bool {
+	if check == nil {
+		return nil
+	}
+	return func(_ Reporter, actual T) bool {
+		return check(actual)
+	}
 }
 
 // WantStdout indicates that the output of the command should be exactly expected.
+// On a mismatch, the differences between expected and the output are reported.
 func (c *Cmd) WantStdout(expected string) {
-	c.checkOut = func(actual string) bool {
+	c.checkOut = func(t Reporter, actual string) bool {
+		if actual == expected {
+			return true
+		}
+		c.diffOut(t, "expected", expected, actual)
+		return false
+	}
+}
+
+// Method diffOut reports the differences between the expected and actual output,
+// naming the source of the expected output. As the differences are more useful
+// than the full output, the failure report then omits the output.
+func (c *Cmd) diffOut(t Reporter, source, expected, actual string) {
+	t.Helper()
+	t.Errorf("output differs from %s:\n%s", source, lineDiff(expected, actual))
+	c.outDiffed = true
+}
+
+// CheckCombined sets a function to check the output and error output of the command
+// together, as a single stream, for programs whose interleaving of the two is significant.
+//
+// The check function will be passed everything the command wrote to either stream,
+// in order, and should return whether it is correct. While such a function is set,
+// the checks set by CheckStdout, CheckStderr, and the related Want* methods are
+// ignored, and the error output is considered empty; note that this means the exit
+// code is expected to be 0 unless CheckCode or another method changes that check.
+// If the results are incorrect, the combined output is reported in place of
+// the separate output and error output.
+//
+// CheckCombined(nil), the default, checks the output and error output separately.
+func (c *Cmd) CheckCombined(check func(actual string) bool) {
+	c.checkCombined = ignoreReporter(check)
+}
+
+// WantCombined indicates that the output and error output of the command, taken
+// together as described for CheckCombined, should be exactly expected.
+func (c *Cmd) WantCombined(expected string) {
+	c.checkCombined = func(_ Reporter, actual string) bool {
 		return actual == expected
 	}
 }
 
+// UpdateGolden controls whether WantStdoutFile updates golden files rather than checking them.
+// It is initially true if the environment variable UPDATE is set to a non-empty value,
+// as in "UPDATE=1 go test".
+var UpdateGolden = os.Getenv("UPDATE") != ""
+
+// WantStdoutFile indicates that the output of the command should be exactly
+// the contents of the file path, typically a golden file under testdata.
+//
+// The file is read immediately; if it can not be read, WantStdoutFile reports a fatal error.
+// On a mismatch, only the differences between the file and the output are reported,
+// with a few lines of context, rather than the full expected or actual output.
+//
+// If UpdateGolden is true, the file is not read; instead, each run writes the
+// command's output to the file, and the output is accepted, so that expectations
+// can be regenerated after an intended change.
+func (c *Cmd) WantStdoutFile(t Reporter, path string) {
+	t.Helper()
+	if UpdateGolden {
+		c.checkOut = func(t Reporter, actual string) bool {
+			if e := os.WriteFile(path, []byte(actual), 0o666); e != nil {
+				t.Error(e)
+				return false
+			}
+			return true
+		}
+		return
+	}
+
+	data, e := os.ReadFile(path)
+	if e != nil {
+		t.Fatal(e)
+		return // In case t.Fatal has been overridden to not terminate the test case.
+	}
+	expected := string(data)
+	c.checkOut = func(t Reporter, actual string) bool {
+		if actual == expected {
+			return true
+		}
+		c.diffOut(t, path, expected, actual)
+		return false
+	}
+}
+
+// WantStdoutEmpty indicates that the command should produce no output.
+//
+// This is the default, but WantStdoutEmpty states it explicitly,
+// and restores it after another check has been set.
+func (c *Cmd) WantStdoutEmpty() {
+	c.checkOut = func(t Reporter, actual string) bool {
+		if actual == "" {
+			return true
+		}
+		t.Error("expected no output")
+		return false
+	}
+}
+
+// WantStdoutLine indicates that the output of the command should be exactly expected,
+// with or without a final newline. This suits commands that print a single line.
+func (c *Cmd) WantStdoutLine(expected string) {
+	c.checkOut = func(t Reporter, actual string) bool {
+		if actual == expected || actual == expected+"\n" {
+			return true
+		}
+		c.diffOut(t, "expected", expected+"\n", actual)
+		return false
+	}
+}
+
+// TrimSpace sets whether leading and trailing white space is removed from the output
+// and error output of the command before they are checked.
+//
+// The trimming applies to every check of the output and error output, including
+// the exact matches of WantStdout and WantStderr, the default checks that they are
+// empty, and the choice of expected exit code based on whether there is error output.
+// So, for example, with TrimSpace(true), WantStdout("ok") accepts the output "ok\n",
+// but WantStdout("ok\n") accepts nothing, and error output consisting only of white space
+// is treated as no error output. Failure reports and CaptureInto show the output untrimmed.
+// TrimSpace(false), the default, checks the output and error output exactly as produced.
+func (c *Cmd) TrimSpace(trim bool) {
+	c.trimSpace = trim
+}
+
+// WantStdoutForOS indicates that the output of the command should be exactly
+// expected[runtime.GOOS], allowing for output that differs between platforms.
+//
+// If expected has no entry for the current GOOS, the entry with key "default"
+// is used instead. If there is no such entry either, each run fails, reporting
+// the GOOS and the keys that are present, so that only the tests concerned fail.
+func (c *Cmd) WantStdoutForOS(expected map[string]string) {
+	want, ok := expected[runtime.GOOS]
+	if !ok {
+		want, ok = expected["default"]
+	}
+	if !ok {
+		keys := make([]string, 0, len(expected))
+		for k := range expected {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		c.checkOut = func(t Reporter, actual string) bool {
+			t.Errorf("no expected output for GOOS %s and no default; keys present: %s", runtime.GOOS, strings.Join(keys, ", "))
+			return false
+		}
+		return
+	}
+	c.WantStdout(want)
+}
+
+// WantStdoutSorted indicates that the lines of the command's output
+// should be in ascending order, as compared by strings.Compare.
+//
+// The first pair of lines found out of order is reported.
+func (c *Cmd) WantStdoutSorted() {
+	c.WantStdoutSortedFunc(strings.Compare)
+}
+
+// WantStdoutSortedFunc indicates that the lines of the command's output
+// should be in ascending order, as determined by cmp.
+//
+// As with slices.SortFunc, cmp(a, b) should return a negative number when a < b,
+// a positive number when a > b, and zero when a == b.
+// The first pair of lines found out of order is reported.
+func (c *Cmd) WantStdoutSortedFunc(cmp func(a, b string) int) {
+	c.checkOut = func(t Reporter, actual string) bool {
+		lines := splitLines(actual)
+		for i := 1; i < len(lines); i++ {
+			if cmp(lines[i-1], lines[i]) > 0 {
+				t.Errorf("output lines %d and %d are out of order: %q, %q", i, i+1, lines[i-1], lines[i])
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// WantStdoutContains indicates that the output of the command should contain substr.
+func (c *Cmd) WantStdoutContains(substr string) {
+	c.checkOut = func(t Reporter, actual string) bool {
+		if strings.Contains(actual, substr) {
+			return true
+		}
+		t.Errorf("output did not contain %q", substr)
+		return false
+	}
+}
+
+// WantStdoutMatch indicates that re should match somewhere in the output of the command.
+// This suits output that varies from run to run, such as output including times or
+// process IDs. WantStdoutMatch panics if re is nil.
+func (c *Cmd) WantStdoutMatch(re *regexp.Regexp) {
+	if re == nil {
+		panic("gotest.Cmd.WantStdoutMatch: nil regular expression")
+	}
+	c.checkOut = func(t Reporter, actual string) bool {
+		if re.MatchString(actual) {
+			return true
+		}
+		t.Errorf("output did not match %q", re.String())
+		return false
+	}
+}
+
+// WantStdoutNotMatch indicates that the output of the command should contain
+// no match for the regular expression pattern, as for a command that must not
+// print a secret or a deprecated term.
+//
+// The pattern is compiled immediately; WantStdoutNotMatch panics if it is invalid.
+// On failure, the first match found is reported.
+func (c *Cmd) WantStdoutNotMatch(pattern string) {
+	re := regexp.MustCompile(pattern)
+	c.checkOut = func(t Reporter, actual string) bool {
+		loc := re.FindStringIndex(actual)
+		if loc == nil {
+			return true
+		}
+		t.Errorf("output matches %q at offset %d: %q", pattern, loc[0], actual[loc[0]:loc[1]])
+		return false
+	}
+}
+
+// WantEcho indicates that the output of the command should be exactly the input
+// given to it on each run, as for a filter that passes its input through unchanged.
+//
+// On a mismatch, the differences between the input and the output are reported.
+func (c *Cmd) WantEcho() {
+	c.checkOut = func(t Reporter, actual string) bool {
+		if c.input == nil {
+			t.Error("output can not be compared to input that was not retained")
+			return false
+		}
+		if actual == *c.input {
+			return true
+		}
+		c.diffOut(t, "input", *c.input, actual)
+		return false
+	}
+}
+
 // WantStderr indicates that the error output of the command should be exactly expected.
 func (c *Cmd) WantStderr(expected string) {
-	c.checkErr = func(actual string) bool {
+	c.setCheckErr(func(_ Reporter, actual string) bool {
 		return actual == expected
+	})
+}
+
+// WantStderrEmpty indicates that the command should produce no error output.
+//
+// This is the default, but WantStderrEmpty states it explicitly,
+// and restores it after another check has been set.
+func (c *Cmd) WantStderrEmpty() {
+	c.setCheckErr(func(t Reporter, actual string) bool {
+		if actual == "" {
+			return true
+		}
+		t.Error("expected no error output")
+		return false
+	})
+}
+
+// WantStderrContains indicates that the error output of the command should contain substr.
+// Note that unless CheckCode or WantCode is also used, the exit code is expected
+// to be non-0 whenever there is error output.
+func (c *Cmd) WantStderrContains(substr string) {
+	c.setCheckErr(func(t Reporter, actual string) bool {
+		if strings.Contains(actual, substr) {
+			return true
+		}
+		t.Errorf("error output did not contain %q", substr)
+		return false
+	})
+}
+
+// WantStderrMatch indicates that re should match somewhere in the error output of the command.
+// WantStderrMatch panics if re is nil. As with WantStderrContains, unless CheckCode or
+// WantCode is also used, the exit code is expected to be non-0 whenever there is error output.
+func (c *Cmd) WantStderrMatch(re *regexp.Regexp) {
+	if re == nil {
+		panic("gotest.Cmd.WantStderrMatch: nil regular expression")
 	}
+	c.setCheckErr(func(t Reporter, actual string) bool {
+		if re.MatchString(actual) {
+			return true
+		}
+		t.Errorf("error output did not match %q", re.String())
+		return false
+	})
+}
+
+// AllowStderrLines indicates that each line of the command's error output
+// should be one of the allowed lines.
+//
+// This suits commands that may print any of a known set of warnings, in varying
+// order or number. Each line that is not allowed is reported. Empty error output
+// is acceptable. Note that unless CheckCode or WantCode is also used, the exit code
+// is expected to be non-0 whenever there is error output.
+func (c *Cmd) AllowStderrLines(allowed []string) {
+	set := make(map[string]bool, len(allowed))
+	for _, line := range allowed {
+		set[line] = true
+	}
+	c.setCheckErr(func(t Reporter, actual string) bool {
+		ok := true
+		for _, line := range splitLines(actual) {
+			if !set[line] {
+				t.Errorf("unexpected error output line: %q", line)
+				ok = false
+			}
+		}
+		return ok
+	})
 }
 
 // WantCode indicates that the exit code of the command should be expected.
 func (c *Cmd) WantCode(expected int) {
-	c.checkCode = func(actual int) bool {
+	c.setCheckCode(func(_ Reporter, actual int) bool {
 		return actual == expected
+	})
+}
+
+// WantSuccess indicates that the exit code of the command should be 0.
+//
+// This differs from the default check, which expects 0 only when there is
+// no error output: with WantSuccess, the code must be 0 even if the command
+// writes error output, as for a command that prints warnings. The error output
+// itself is still checked, as set by CheckStderr or the related Want* methods.
+func (c *Cmd) WantSuccess() {
+	c.setCheckCode(func(t Reporter, actual int) bool {
+		if actual == 0 {
+			return true
+		}
+		t.Errorf("exit code %d; expected 0", actual)
+		return false
+	})
+}
+
+// WantAnyFailure indicates that the exit code of the command should be non-0.
+//
+// Only the check on the exit code is changed; the output and error output are
+// still checked, as set by CheckStdout, CheckStderr, or the related Want* methods.
+// By default the command is then expected to fail without writing error output,
+// so WantAnyFailure is usually combined with a check such as WantStderrContains.
+// Compare WantFailure, which also replaces the check on the error output.
+func (c *Cmd) WantAnyFailure() {
+	c.setCheckCode(func(t Reporter, actual int) bool {
+		if actual != 0 {
+			return true
+		}
+		t.Error("exit code 0; expected failure")
+		return false
+	})
+}
+
+// WantCodeNot indicates that the exit code of the command should be anything but forbidden.
+func (c *Cmd) WantCodeNot(forbidden int) {
+	c.setCheckCode(func(t Reporter, actual int) bool {
+		if actual != forbidden {
+			return true
+		}
+		t.Errorf("exit code %d; expected any code but %d", actual, forbidden)
+		return false
+	})
+}
+
+// WantStreaming indicates that the command's output should arrive progressively,
+// in at least minChunks separate chunks, rather than all at once.
+//
+// A chunk is a series of writes that follow closely on one another; writes separated
+// by more than a few milliseconds are in different chunks. This can be used to test
+// that a command flushes its output as it goes, rather than only when it exits.
+//
+// WantStreaming(0), the default, does not check how the output arrives.
+func (c *Cmd) WantStreaming(minChunks int) {
+	c.minChunks = minChunks
+}
+
+// WantCodeNamed indicates that the exit code of the command should be table[name].
+//
+// This allows tests to refer to exit codes by name, for commands that follow conventions
+// such as those of sysexits.h. On a mismatch, the expected name and code are reported,
+// along with the name of the actual code if it is in table. WantCodeNamed panics if
+// name is not in table.
+func (c *Cmd) WantCodeNamed(name string, table map[string]int) {
+	expected, ok := table[name]
+	if !ok {
+		panic("gotest.Cmd.WantCodeNamed: no exit code named " + name)
+	}
+	actualName := make(map[int]string, len(table))
+	for n, code := range table {
+		if prev, dup := actualName[code]; !dup || n < prev {
+			actualName[code] = n
+		}
+	}
+	c.setCheckCode(func(t Reporter, actual int) bool {
+		if actual == expected {
+			return true
+		}
+		if n, ok := actualName[actual]; ok {
+			t.Errorf("exit code %d (%s); expected %d (%s)", actual, n, expected, name)
+		} else {
+			t.Errorf("exit code %d; expected %d (%s)", actual, expected, name)
+		}
+		return false
+	})
+}
+
+// WantFailure indicates that the command should fail, with an exit code in codes,
+// and with error output containing stderrSubstr.
+//
+// If codes is empty, any non-0 exit code is acceptable; 0 is never acceptable.
+// So WantFailure(nil, "") accepts any failure, with any error output.
+// WantFailure replaces any earlier check on the error output; to keep such a check,
+// use WantAnyFailure instead. WantFailure does not change the check on the command's
+// output, which by default expects no output at all.
+func (c *Cmd) WantFailure(codes []int, stderrSubstr string) {
+	codes = append([]int(nil), codes...)
+	c.setCheckCode(func(t Reporter, actual int) bool {
+		if actual == 0 {
+			t.Error("exit code 0; expected failure")
+			return false
+		}
+		if len(codes) == 0 || slices.Contains(codes, actual) {
+			return true
+		}
+		t.Errorf("exit code %d is not one of %v", actual, codes)
+		return false
+	})
+	c.setCheckErr(func(t Reporter, actual string) bool {
+		if strings.Contains(actual, stderrSubstr) {
+			return true
+		}
+		t.Errorf("error output did not contain %q", stderrSubstr)
+		return false
+	})
+}
+
+// RunUsage runs the command once with a help flag added to its arguments, and checks
+// that it prints usage text containing substr and exits with the usual code for help.
+//
+// By default, the flag is "--help" and the exit code 0; use UsageConvention
+// to change these. Typically substr is the program name or "Usage:", so that a test
+// can verify cheaply that the help for each subcommand is available. The error output
+// is expected to be empty. The run uses the command's working directory, environment,
+// timeout, and cgroup limits, but not its other checks, and the command's arguments
+// and checks are left unchanged for later runs.
+func (c *Cmd) RunUsage(t Reporter, substr string) {
+	t.Helper()
+	flag, expected := c.helpFlag, c.helpCode
+	u := Command(c.name, append(slices.Clip(c.args), flag)...)
+	u.dir, u.env, u.clearEnv = c.dir, c.env, c.clearEnv
+	u.timeout, u.memLimit, u.cpuQuota = c.timeout, c.memLimit, c.cpuQuota
+	u.WantStdoutContains(substr)
+	u.setCheckCode(func(t Reporter, actual int) bool {
+		if actual == expected {
+			return true
+		}
+		t.Errorf("exit code %d; expected %d after %s", actual, expected, flag)
+		return false
+	})
+	u.Run(t, "")
+	c.lastCode = u.lastCode
+}
+
+// UsageConvention sets the flag that RunUsage adds to request help, and the exit
+// code expected with it. The defaults are "--help" and 0; some programs instead use
+// a flag such as "-h", or exit with code 2 after printing usage.
+func (c *Cmd) UsageConvention(flag string, code int) {
+	c.helpFlag = flag
+	c.helpCode = code
+}
+
+// WantNoExtraFds indicates that running the command should not leave any
+// additional file descriptors open in the test process.
+//
+// Run lists the open file descriptors before and after running the command,
+// and reports any new ones along with their targets. This can detect leaks in
+// the plumbing around the command, such as pipes or files left open.
+// Note that descriptors opened concurrently by other goroutines, including
+// those of parallel tests, will also be reported.
+//
+// The check is only available on Linux, where it uses /proc/self/fd;
+// on other systems WantNoExtraFds has no effect.
+func (c *Cmd) WantNoExtraFds() {
+	c.checkFds = true
+}
+
+// ThrottleStdin limits the rate at which input is fed to the command to bytesPerSec.
+//
+// This simulates a slow producer, exercising the command's handling of input
+// that arrives gradually. ThrottleStdin(0), the default, feeds input as fast
+// as the command will read it.
+func (c *Cmd) ThrottleStdin(bytesPerSec int) {
+	c.throttle = bytesPerSec
+}
+
+// WantThroughput indicates that the command should process bytes bytes of input
+// within the given time.
+//
+// Run then feeds the command bytes bytes of generated text, in lines of 64 bytes,
+// instead of the input passed to Run, which must be empty. It checks that the command
+// finishes within the time allowed, and reports the measured rate if not. The command's
+// output is checked as usual; use CheckStdout to accept it.
+//
+// This is a coarse check, intended to catch large performance regressions in
+// commands that filter data. WantThroughput(0, 0), the default, feeds the input
+// passed to Run and does not time the command. WantThroughput panics if either
+// argument is negative, or if within is positive but bytes is not, since there
+// would then be no input to measure the throughput of.
+func (c *Cmd) WantThroughput(bytes int64, within time.Duration) {
+	if bytes < 0 || within < 0 {
+		panic("gotest.Cmd.WantThroughput: negative argument")
+	}
+	if within > 0 && bytes == 0 {
+		panic("gotest.Cmd.WantThroughput: a time limit requires a positive number of bytes")
+	}
+	c.generated = bytes
+	c.within = within
+}
+
+// WantFilesCreated indicates that after the command runs, the files in its working
+// directory should be exactly those listed.
+//
+// The paths are relative to the working directory, and use forward slashes as separators.
+// Subdirectories are searched, and all files found other than directories are compared
+// with paths; any missing or unexpected files are reported. Note that files present
+// before the command runs are included, so the directory should usually start out empty.
+func (c *Cmd) WantFilesCreated(paths ...string) {
+	c.checkFiles = true
+	c.wantFiles = append([]string(nil), paths...)
+}
+
+// Method checkCreated checks the files in dir against those listed by WantFilesCreated.
+func (c *Cmd) checkCreated(t Reporter, dir string) bool {
+	t.Helper()
+	if dir == "" {
+		dir = "."
+	}
+	found := make(map[string]bool)
+	e := filepath.WalkDir(dir, func(path string, d fs.DirEntry, e error) error {
+		if e != nil || d.IsDir() {
+			return e
+		}
+		rel, e := filepath.Rel(dir, path)
+		found[filepath.ToSlash(rel)] = true
+		return e
+	})
+	if e != nil {
+		t.Error(e)
+		return false
+	}
+
+	ok := true
+	for _, path := range c.wantFiles {
+		if found[path] {
+			delete(found, path)
+		} else {
+			t.Error("missing file:", path)
+			ok = false
+		}
+	}
+	unexpected := make([]string, 0, len(found))
+	for path := range found {
+		unexpected = append(unexpected, path)
+	}
+	sort.Strings(unexpected)
+	for _, path := range unexpected {
+		t.Error("unexpected file:", path)
+		ok = false
 	}
+	return ok
+}
+
+// WantExitCategory indicates how the command should finish, in broad terms:
+// whether it should succeed, fail, or be terminated by a signal.
+//
+// This replaces any check on the exit code set by CheckCode, WantCode, or WantFailure;
+// likewise, those methods replace the check set by WantExitCategory.
+// When Signal is expected, a command terminated by a signal does not cause a fatal
+// error; instead, its output is checked as usual. Likewise, when Timeout is expected,
+// a command killed by Timeout has its output checked as usual, and a command that
+// finishes in time is reported as having the wrong category.
+func (c *Cmd) WantExitCategory(cat ExitCategory) {
+	c.setCheckCode(nil)
+	c.category = cat
+}
+
+// WantCleanSuccess indicates that the command should be quiet if it succeeds,
+// and explain itself if it fails.
+//
+// If the exit code is 0, there should be no error output, and the output is checked
+// per CheckStdout or WantStdout as usual. If the exit code is not 0, there should be
+// some error output, and the output is not checked at all.
+//
+// This replaces any checks on the error output and exit code; calling any method that
+// sets those checks, such as WantStderr or WantCode, in turn cancels WantCleanSuccess.
+func (c *Cmd) WantCleanSuccess() {
+	c.setCheckErr(nil)
+	c.setCheckCode(nil)
+	c.cleanSuccess = true
+}
+
+// WantHandlesLargeInput indicates that the command should accept size bytes of input
+// without error: it should exit with code 0 and produce no error output.
+//
+// As with WantThroughput, Run feeds the command generated text, in lines of 64 bytes,
+// instead of the input passed to Run, which must be empty. The text is generated as
+// it is needed, so even very large sizes do not use much memory. If the command fails,
+// the report notes how much of the input it read. The command's output is checked
+// as usual; use CheckStdout to accept it.
+//
+// This replaces any checks on the error output and exit code, and any time limit set
+// by WantThroughput. WantHandlesLargeInput panics if size is negative.
+func (c *Cmd) WantHandlesLargeInput(size int) {
+	if size < 0 {
+		panic("gotest.Cmd.WantHandlesLargeInput: negative size")
+	}
+	c.generated = int64(size)
+	c.within = 0
+	c.setCheckErr(nil)
+	c.WantCode(0)
+}
+
+// WantCombinedLineCount indicates that the command should write exactly n lines in total,
+// counting both its output and its error output.
+//
+// The lines of each stream are counted separately and the counts added, so the
+// result does not depend on how writes to the two streams are interleaved.
+// A final line without a newline is counted. The separate streams are still
+// checked as usual.
+func (c *Cmd) WantCombinedLineCount(n int) {
+	c.checkLines = true
+	c.combinedLines = n
+}
+
+// Timeout limits the time allowed for each run of the command to d.
+//
+// If a run takes longer, the command is killed, along with any processes it has
+// started on systems with process groups, rather than leaving the test to hang.
+// Run then reports the timeout, the command, and whatever output and error output
+// were captured, and calls t.FailNow; the Check* and Want* methods are not applied.
+// With WantExitCategory(Timeout), the timeout is instead expected, and the output
+// and error output are checked as usual.
+// Each run is allowed the full time d. A zero d, the default, means no limit.
+func (c *Cmd) Timeout(d time.Duration) {
+	c.timeout = d
+}
+
+// Setenv sets the environment variable key to value for each run of the command.
+//
+// The command inherits the environment of the test process, unless ClearEnv
+// has been called, with the variables given to Setenv added or replaced.
+// Calling Setenv again with the same key replaces the earlier value.
+// Variables given to RunWith by WithEnv take precedence over those set by Setenv.
+// If a run fails, the variables are listed in the failure report.
+// Setenv panics if key is empty or contains '='.
+func (c *Cmd) Setenv(key, value string) {
+	if key == "" || strings.Contains(key, "=") {
+		panic("gotest.Cmd.Setenv: invalid environment variable name " + strconv.Quote(key))
+	}
+	kv := key + "=" + value
+	for i, old := range c.env {
+		if strings.HasPrefix(old, key+"=") {
+			c.env[i] = kv
+			return
+		}
+	}
+	c.env = append(c.env, kv)
+}
+
+// ClearEnv makes the command run with an environment containing only the variables
+// set by Setenv and those given to RunWith by WithEnv, rather than inheriting
+// the environment of the test process. With no such variables, the environment
+// is empty. ClearEnv resets only the base environment; variables set by Setenv,
+// before or after the call to ClearEnv, are kept.
+//
+// This makes a command's behavior independent of the environment in which the
+// tests are run. Note that PATH is also cleared, so the command name should
+// normally be an absolute path, and any programs the command itself runs
+// may not be found unless PATH is set with Setenv.
+func (c *Cmd) ClearEnv() {
+	c.clearEnv = true
+}
+
+// CgroupLimit runs the command with limits on its memory and CPU use, so that tests
+// can verify how it behaves when resources are scarce.
+//
+// The command is limited to memBytes bytes of memory, and to cpuQuota CPUs' worth
+// of time; for example, 0.5 allows half of one CPU. A zero value leaves that resource
+// unlimited, and CgroupLimit(0, 0), the default, removes both limits.
+//
+// The limits are applied with a new cgroup for each run. This requires Linux with
+// cgroup v2. Since cgroup v2 does not allow a cgroup that contains processes to pass
+// controllers to child cgroups, the new cgroups are created beside the cgroup of the
+// test process, in its parent; that parent must be writable, and have the memory and
+// cpu controllers, as needed, enabled in its cgroup.subtree_control. For example,
+// in a systemd unit or container with cgroup delegation, move the shell that runs
+// go test into a new child of the delegated cgroup, and enable the controllers in
+// the delegated cgroup. Only if the test process is in a root cgroup are the new
+// cgroups created below it instead. CgroupLimit never moves processes or enables
+// controllers itself; if the cgroup can not be set up, Run reports a fatal error
+// explaining why.
+//
+// A command that exceeds its memory limit is usually killed by the kernel with
+// SIGKILL; use WantExitCategory(Signal) to expect that. CgroupLimit panics if
+// either limit is negative.
+func (c *Cmd) CgroupLimit(memBytes int64, cpuQuota float64) {
+	if memBytes < 0 || cpuQuota < 0 {
+		panic("gotest.Cmd.CgroupLimit: negative limit")
+	}
+	c.memLimit = memBytes
+	c.cpuQuota = cpuQuota
+}
+
+// AppendArgs adds args to the end of the command's arguments.
+//
+// This allows a Cmd with common arguments to be extended for each test case.
+// Note that the added arguments remain for later runs; to start again from
+// the common arguments, use SetArgs.
+func (c *Cmd) AppendArgs(args ...string) {
+	c.args = append(slices.Clip(c.args), args...)
+}
+
+// WithConfigFile writes content to a new file in a temporary directory created by
+// t.TempDir, and returns the path of the file, for use in the command's arguments,
+// as in c.AppendArgs("--config=" + c.WithConfigFile(t, content)).
+//
+// The file is removed, with its directory, when the test finishes.
+// If the file can not be written, WithConfigFile reports a fatal error.
+func (c *Cmd) WithConfigFile(t Reporter, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if e := os.WriteFile(path, []byte(content), 0o666); e != nil {
+		t.Fatal(e)
+		return "" // In case t.Fatal has been overridden to not terminate the test case.
+	}
+	return path
+}
+
+// SetArgs replaces the command's arguments with args.
+func (c *Cmd) SetArgs(args ...string) {
+	c.args = slices.Clone(args)
 }
 
 // Chdir sets the working directory where the command will be run.
@@ -98,6 +1091,69 @@ func (c *Cmd) Chdir(path string) {
 	c.dir = path
 }
 
+// CaptureInto sets buffers to receive the output and error output of the command.
+//
+// Each call to Run resets the buffers before starting the command, so after Run
+// returns they hold exactly what the command wrote during that run. The output is
+// still checked as usual per the Check* and Want* methods; CaptureInto merely lets
+// the caller retain it for further processing.
+//
+// Either buffer may be nil, in which case Run uses a private buffer for that stream.
+// CaptureInto(nil, nil), the default, retains neither.
+func (c *Cmd) CaptureInto(stdout, stderr *bytes.Buffer) {
+	c.outBuf = stdout
+	c.errBuf = stderr
+}
+
+// Capture runs the external command and returns its results without checking them.
+//
+// The content of input is passed to the command as its stdin. Capture returns
+// the command's output, error output, and exit code; the Check* and Want* methods
+// have no effect on it.
+//
+// If the command can not be started or is terminated by a signal, Capture
+// reports a fatal error through t and also returns that error as err.
+// Otherwise err is nil, even when the exit code is not 0.
+func (c *Cmd) Capture(t Reporter, input string) (stdout, stderr string, code int, err error) {
+	t.Helper()
+	r, e := c.execute(c.config(WithInput(input)))
+	if e != nil {
+		t.Fatal(e)
+		return "", "", r.code, e
+	}
+	return r.out.String(), r.err.String(), r.code, nil
+}
+
+// WantDeterministicAcrossEnv runs the command once for each set of environment variables
+// in envSets, and verifies that it produces the same output each time.
+//
+// Each set holds variables of the form "key=value", added to the environment as by WithEnv.
+// The content of input is passed to the command on every run. This can catch output that
+// varies with settings such as the locale or time zone. Only the output is compared;
+// the Check* and Want* methods have no effect. If the output of a run differs from
+// that of the first run, both environment sets and the differences are reported.
+// As with Capture, failure to start the command or its termination by a signal
+// is a fatal error.
+func (c *Cmd) WantDeterministicAcrossEnv(t Reporter, input string, envSets [][]string) {
+	t.Helper()
+	var first string
+	for i, env := range envSets {
+		r, e := c.execute(c.config(WithInput(input), WithEnv(env...)))
+		if e != nil {
+			t.Fatal(e)
+			return // In case t.Fatal has been overridden to not terminate the test case.
+		}
+		if i == 0 {
+			first = r.out.String()
+		} else if actual := r.out.String(); actual != first {
+			t.Errorf("output with environment %q differs from output with environment %q:\n%s",
+				env, envSets[0], lineDiff(first, actual))
+			t.FailNow()
+			return
+		}
+	}
+}
+
 // Run runs the external command and checks the results.
 //
 // The content of input is passed to the command as its stdin.
@@ -110,6 +1166,8 @@ func (c *Cmd) Chdir(path string) {
 //
 // If the command can not be started or is terminated by a signal,
 // Run will report a fatal error and skip checking the command results.
+// The exception is a signal when WantExitCategory has been used;
+// the results are then checked as usual.
 //
 // It is permissible to call Run multiple times on the same Cmd object,
 // in order to test the same external command with varying inputs.
@@ -117,57 +1175,208 @@ func (c *Cmd) Chdir(path string) {
 // if the expected results will change.
 func (c *Cmd) Run(t Reporter, input string) {
 	t.Helper()
-	if c.name == "" {
-		panic("gotest.Cmd not initialized; use gotest.Command to create Cmds")
-	}
+	c.RunContext(context.Background(), t, input)
+}
 
-	cmd := exec.Command(c.name, c.args...)
-	cmd.Stdin = strings.NewReader(input)
-	cmd.Dir = c.dir
+// RunContext runs the external command and checks the results, as Run does,
+// but kills the command if ctx is done before the command finishes.
+//
+// On systems with process groups, any processes started by the command are
+// killed as well. If the command is killed in this way, RunContext reports
+// the command and the context's error as a fatal error, without checking
+// the command's results.
+func (c *Cmd) RunContext(ctx context.Context, t Reporter, input string) {
+	t.Helper()
+	c.RunWith(t, WithInput(input), func(rc *runConfig) {
+		rc.ctx = ctx
+	})
+}
 
-	var out, err strings.Builder
-	cmd.Stdout = &out
-	cmd.Stderr = &err
-	e := cmd.Run()
+// RunWith runs the external command and checks the results, as Run does.
+//
+// The options adjust this run only; they do not change the Cmd, so it is
+// safe to build a separate set of options for each row of a table test.
+// Note that RunWith, like Run, is not safe for concurrent use on a single Cmd.
+func (c *Cmd) RunWith(t Reporter, opts ...RunOption) {
+	t.Helper()
+	c.run(t, opts...)
+}
+
+// RunCapture runs the external command and checks the results, as Run does,
+// and also returns the command's output, error output, and exit code.
+//
+// This allows further checks that the Check* and Want* methods do not provide,
+// such as parsing binary output. As Run does, RunCapture calls t.FailNow if the
+// results are incorrect, so the values returned can be relied on as having passed
+// the checks. The slices are copies, which remain valid after later runs.
+// If the command can not be run at all, the slices are nil and the code is -1.
+// If the command is killed by Timeout, the slices hold whatever was captured
+// and the code is -1.
+func (c *Cmd) RunCapture(t Reporter, input string) (stdout, stderr []byte, code int) {
+	t.Helper()
+	r := c.run(t, WithInput(input))
+	if r == nil {
+		return nil, nil, -1
+	}
+	code = r.code
+	if r.timedOut {
+		code = -1
+	}
+	return bytes.Clone(r.out.Bytes()), bytes.Clone(r.err.Bytes()), code
+}
 
-	code := 0
+// RunReader runs the external command and checks the results, as Run does,
+// but with the command's stdin read from input.
+//
+// The data is passed to the command as it is read, so large inputs need not be
+// held in memory. As the data is not retained, a failure report does not show it,
+// and WantEcho can not be used. The reader is not closed.
+func (c *Cmd) RunReader(t Reporter, input io.Reader) {
+	t.Helper()
+	c.run(t, func(rc *runConfig) {
+		rc.reader = input
+	})
+}
+
+// RunFile runs the external command and checks the results, as Run does,
+// but with the command's stdin read from the file at inputPath.
+//
+// As with RunReader, the data is passed to the command as it is read, and is not retained;
+// a failure report names the file rather than showing its contents. If the file cannot
+// be opened, t.Fatal is called before the command is run.
+func (c *Cmd) RunFile(t Reporter, inputPath string) {
+	t.Helper()
+	f, e := os.Open(inputPath)
 	if e != nil {
-		ee, ok := e.(*exec.ExitError)
-		if ok {
-			code = ee.ExitCode()
-			ok = ee.Exited()
-		}
-		if !ok {
+		c.lastCode = -1
+		t.Fatalf("cannot open input file %s: %v", inputPath, e)
+		return // In case t.Fatal has been overridden to not terminate the test case.
+	}
+	defer f.Close()
+	c.run(t, func(rc *runConfig) {
+		rc.reader = f
+		rc.file = inputPath
+	})
+}
+
+// RunPiped runs a two-stage pipeline, as for "producer | c", and checks the results
+// of both stages.
+//
+// First producer is run with input as its stdin, and its results are checked as by Run.
+// If they are correct, c is then run with the output of producer as its stdin, and its
+// results are checked likewise. When a stage fails, the usual report is followed by
+// a line naming the stage, and t.FailNow is called; if producer fails, c is not run.
+func (c *Cmd) RunPiped(t Reporter, producer *Cmd, input string) {
+	t.Helper()
+	st := &stageReporter{Reporter: t, stage: "producer"}
+	r := producer.run(st, WithInput(input))
+	if r == nil || st.failed {
+		return
+	}
+	c.run(&stageReporter{Reporter: t, stage: "consumer"}, WithInput(r.out.String()))
+}
+
+// LastCode returns the exit code of the command from the most recent call to Run,
+// or to another of the Run* methods.
+//
+// This allows further checks on the code after a run that accepted a range of codes.
+// The value is meaningful only if the run did not call t.FailNow. LastCode returns -1
+// if the command has not been run, or if the most recent run failed to start the
+// command or was stopped by Timeout or a context.
+func (c *Cmd) LastCode() int {
+	return c.lastCode
+}
+
+// Method run runs the external command with the given options and checks the results,
+// as described for Run. It returns the results, or nil if the command could not be run.
+func (c *Cmd) run(t Reporter, opts ...RunOption) *result {
+	t.Helper()
+	rc := c.config(opts...)
+	rc.combined = c.checkCombined != nil
+	c.input = rc.retained()
+	c.lastCode = -1
+	c.outDiffed = false
+
+	var fdsBefore map[string]string
+	if c.checkFds {
+		var e error
+		if fdsBefore, e = openFds(); e != nil {
 			t.Fatal(e)
-			return // In case t.Fatal has been overridden to not terminate the test case.
+			return nil // In case t.Fatal has been overridden to not terminate the test case.
 		}
 	}
 
+	r, e := c.execute(rc)
+	if r.timedOut && c.category != Timeout {
+		t.Error(e)
+		c.report(t, r, rc)
+		return r
+	}
+	if r.canceled {
+		t.Fatal(e)
+		return nil
+	}
+	if e != nil && !r.timedOut && !(r.signaled && c.category != 0) {
+		t.Fatal(e)
+		return nil
+	}
+	if !r.timedOut {
+		c.lastCode = r.code
+	}
+	out, err, code := r.out.String(), r.err.String(), r.code
+	if c.trimSpace {
+		out, err = strings.TrimSpace(out), strings.TrimSpace(err)
+	}
+
 	ok := true
 
-	if c.checkOut == nil {
-		if out.Len() > 0 {
+	if c.checkCombined != nil {
+		if !c.checkCombined(t, out) {
+			t.Error("incorrect combined output")
+			ok = false
+		}
+	} else if c.cleanSuccess && code != 0 {
+		// The output of a failed command is not checked.
+	} else if c.checkOut == nil {
+		if len(out) > 0 {
 			t.Error("unexpected output")
 			ok = false
 		}
-	} else if !c.checkOut(out.String()) {
+	} else if !c.checkOut(t, out) {
 		t.Error("incorrect output")
 		ok = false
 	}
 
-	if c.checkErr == nil {
-		if err.Len() > 0 {
+	if c.cleanSuccess {
+		if code == 0 && len(err) > 0 {
+			t.Error("error output produced but exit code was 0")
+			ok = false
+		} else if code != 0 && len(err) == 0 {
+			t.Error("non-zero exit code but no error output")
+			ok = false
+		}
+	} else if c.checkCombined != nil {
+		// The error output is included in the combined output, checked above.
+	} else if c.checkErr == nil {
+		if len(err) > 0 {
 			t.Error("unexpected error output")
 			ok = false
 		}
-	} else if !c.checkErr(err.String()) {
+	} else if !c.checkErr(t, err) {
 		t.Error("incorrect error output")
 		ok = false
 	}
 
-	if c.checkCode == nil {
+	if c.category != 0 {
+		if actual := r.category(); actual != c.category {
+			t.Errorf("exit category %v; expected %v", actual, c.category)
+			ok = false
+		}
+	} else if c.cleanSuccess {
+		// Any exit code is acceptable; the error output has been checked accordingly.
+	} else if c.checkCode == nil {
 		if ok {
-			if err.Len() == 0 {
+			if len(err) == 0 {
 				if code != 0 {
 					t.Error("non-zero exit code")
 					ok = false
@@ -179,37 +1388,390 @@ func (c *Cmd) Run(t Reporter, input string) {
 				}
 			}
 		}
-	} else if !c.checkCode(code) {
+	} else if !c.checkCode(t, code) {
 		t.Error("incorrect exit code")
 		ok = false
 	}
 
-	if !ok {
-		if len(c.args) == 0 {
-			t.Errorf("command: %s", c.name)
-		} else {
-			t.Errorf("command: %s %s", c.name, strings.Join(c.args, " "))
+	if c.within > 0 && r.elapsed > c.within {
+		t.Errorf("processed %d bytes in %v (%.2f MB/s); expected at most %v",
+			c.generated, r.elapsed, float64(c.generated)/1e6/r.elapsed.Seconds(), c.within)
+		ok = false
+	}
+
+	if c.checkFiles && !c.checkCreated(t, rc.dir) {
+		ok = false
+	}
+
+	if c.checkLines {
+		if actual := len(splitLines(out)) + len(splitLines(err)); actual != c.combinedLines {
+			t.Errorf("output and error output have %d line(s) in total; expected %d", actual, c.combinedLines)
+			ok = false
 		}
-		if len(input) == 0 {
-			t.Error("no input")
-		} else {
-			// Not t.Error(...), in case the input ends with a newline.
-			t.Errorf("input:\n%s", input)
+	}
+
+	if c.minChunks > 0 && r.chunks < c.minChunks {
+		t.Errorf("output arrived in %d chunk(s); expected at least %d", r.chunks, c.minChunks)
+		ok = false
+	}
+
+	if fdsBefore != nil {
+		fdsAfter, e := openFds()
+		if e != nil {
+			t.Fatal(e)
+			return r
+		}
+		var leaked []string
+		for fd, target := range fdsAfter {
+			if fdsBefore[fd] != target {
+				leaked = append(leaked, fd)
+			}
 		}
+		if len(leaked) > 0 {
+			sort.Strings(leaked)
+			t.Errorf("%d file descriptor(s) leaked", len(leaked))
+			for _, fd := range leaked {
+				t.Errorf("fd %s: %s", fd, fdsAfter[fd])
+			}
+			ok = false
+		}
+	}
+
+	if !ok {
+		c.report(t, r, rc)
+	}
+	return r
+}
+
+// Method report records through t the command executed, the environment variables
+// set by Setenv, its input, output, error output, exit code, and the time it took,
+// and then calls t.FailNow.
+func (c *Cmd) report(t Reporter, r *result, rc runConfig) {
+	t.Helper()
+	input, out, err, code := rc.input, r.out, r.err, r.code
+	t.Errorf("command: %s", c.commandLine())
+	for _, kv := range c.env {
+		t.Errorf("environment: %s", kv)
+	}
+	if c.generated > 0 && r.fed < c.generated {
+		t.Errorf("input: %d generated bytes, of which at most %d were read", c.generated, r.fed)
+	} else if c.generated > 0 {
+		t.Errorf("input: %d generated bytes", c.generated)
+	} else if rc.file != "" {
+		t.Errorf("input: (from file %s)", rc.file)
+	} else if rc.reader != nil {
+		t.Error("input: (from io.Reader)")
+	} else if len(input) == 0 {
+		t.Error("no input")
+	} else {
+		// Not t.Error(...), in case the input ends with a newline.
+		t.Errorf("input:\n%s", input)
+	}
+	if c.throttle > 0 {
+		t.Errorf("input throttled to %d bytes/sec", c.throttle)
+	}
+	if c.checkCombined != nil {
 		if out.Len() == 0 {
-			t.Error("no output")
+			t.Error("no combined output")
 		} else {
-			// Don't use t.Error("output:\n" + out.String()); the output usually ends with a newline,
-			// and t.Error always adds another newline.
-			t.Errorf("output:\n%s", out.String())
+			t.Errorf("combined output:\n%s", out.String())
+		}
+	} else if out.Len() == 0 {
+		t.Error("no output")
+	} else if c.outDiffed {
+		t.Error("output: (differences shown above)")
+	} else {
+		// Don't use t.Error("output:\n" + out.String()); the output usually ends with a newline,
+		// and t.Error always adds another newline.
+		t.Errorf("output:\n%s", out.String())
+	}
+	if c.checkCombined != nil {
+		// The error output was included in the combined output.
+	} else if err.Len() == 0 {
+		t.Error("no error output")
+	} else {
+		// Again not using t.Error
+		t.Errorf("error output:\n%s", err.String())
+	}
+	if r.timedOut {
+		t.Error("no exit code; the command was killed")
+	} else {
+		t.Errorf("exit code: %d", code)
+		if r.signal != "" {
+			t.Errorf("terminated by signal: %s", r.signal)
+		} else if exitCodesLimited && !standardCode(code) {
+			t.Errorf("exit code %d is outside the range 0-255", code)
 		}
-		if err.Len() == 0 {
-			t.Error("no error output")
+	}
+	t.Errorf("duration: %v", r.elapsed.Round(time.Millisecond))
+	t.FailNow()
+}
+
+// Method commandLine returns the command and its arguments, separated by spaces.
+func (c *Cmd) commandLine() string {
+	if len(c.args) == 0 {
+		return c.name
+	}
+	return c.name + " " + strings.Join(c.args, " ")
+}
+
+// Type stageReporter passes reports through to another Reporter, naming a stage
+// of a pipeline when the test is stopped.
+type stageReporter struct {
+	Reporter
+	stage  string
+	failed bool
+}
+
+// Method FailNow names the stage that failed, then stops the test through the underlying Reporter.
+func (sr *stageReporter) FailNow() {
+	sr.Helper()
+	sr.failed = true
+	sr.Errorf("pipeline failed in %s", sr.stage)
+	sr.Reporter.FailNow()
+}
+
+// Method Fatal is equivalent to Error followed by FailNow, so that the stage is named.
+func (sr *stageReporter) Fatal(args ...any) {
+	sr.Helper()
+	sr.Error(args...)
+	sr.FailNow()
+}
+
+// Method Fatalf is equivalent to Errorf followed by FailNow, so that the stage is named.
+func (sr *stageReporter) Fatalf(format string, args ...any) {
+	sr.Helper()
+	sr.Errorf(format, args...)
+	sr.FailNow()
+}
+
+// Method stdin returns the source of the input for a run of the command.
+func (rc *runConfig) stdin() io.Reader {
+	if rc.reader != nil {
+		return rc.reader
+	}
+	return strings.NewReader(rc.input)
+}
+
+// Method retained returns the input for a run of the command, or nil if the input
+// is read from an io.Reader, and so is not retained.
+func (rc *runConfig) retained() *string {
+	if rc.reader != nil {
+		return nil
+	}
+	input := rc.input
+	return &input
+}
+
+// Method config returns the settings for a run of the command with the given options.
+func (c *Cmd) config(opts ...RunOption) runConfig {
+	rc := runConfig{ctx: context.Background(), dir: c.dir}
+	for _, opt := range opts {
+		opt(&rc)
+	}
+	return rc
+}
+
+// Method execute runs the external command with the given settings.
+//
+// If the command could not be started or did not exit normally, execute returns a non-nil error.
+// The result is never nil, though its contents may not be meaningful if there is an error.
+func (c *Cmd) execute(rc runConfig) (*result, error) {
+	if c.name == "" {
+		panic("gotest.Cmd not initialized; use gotest.Command to create Cmds")
+	}
+
+	ctx := rc.ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, c.name, c.args...)
+	if ctx.Done() != nil {
+		killGroupOnCancel(cmd)
+		// Don't wait indefinitely for the output if a stray process keeps it open.
+		cmd.WaitDelay = time.Second
+	}
+	if c.verbatim {
+		setCmdLine(cmd, c.commandLine())
+	}
+	cmd.Stdin = rc.stdin()
+	var gr *generatedReader
+	if c.generated > 0 {
+		if rc.input != "" || rc.reader != nil {
+			panic("gotest.Cmd: input given for a command that uses generated input")
+		}
+		gr = &generatedReader{size: c.generated}
+		cmd.Stdin = gr
+	}
+	if c.throttle > 0 {
+		cmd.Stdin = &throttledReader{r: cmd.Stdin, rate: c.throttle}
+	}
+	cmd.Dir = rc.dir
+	if c.clearEnv {
+		// A non-nil empty slice, so the command does not inherit the environment.
+		cmd.Env = append(append([]string{}, c.env...), rc.env...)
+	} else if len(c.env) > 0 || len(rc.env) > 0 {
+		cmd.Env = append(append(os.Environ(), c.env...), rc.env...)
+	}
+
+	var r result
+	r.out, r.err = c.outBuf, c.errBuf
+	if r.out == nil {
+		r.out = new(bytes.Buffer)
+	}
+	if r.err == nil {
+		r.err = new(bytes.Buffer)
+	}
+	r.out.Reset()
+	r.err.Reset()
+	cmd.Stdout = r.out
+	cmd.Stderr = r.err
+
+	var cw *chunkWriter
+	if c.minChunks > 0 {
+		cw = &chunkWriter{w: r.out}
+		cmd.Stdout = cw
+	}
+	if rc.combined {
+		// Using the same Writer for both makes os/exec share a single pipe,
+		// so the order of the data is preserved.
+		cmd.Stderr = cmd.Stdout
+	}
+
+	if c.memLimit > 0 || c.cpuQuota > 0 {
+		cg, e := newCgroup(c.memLimit, c.cpuQuota)
+		if e != nil {
+			return &r, fmt.Errorf("gotest.Cmd: cgroup setup failed: %w", e)
+		}
+		defer cg.remove()
+		cg.attach(cmd)
+	}
+
+	start := time.Now()
+	e := cmd.Run()
+	r.elapsed = time.Since(start)
+
+	if cw != nil {
+		r.chunks = cw.chunks
+	}
+	if gr != nil {
+		r.fed = gr.offset
+	}
+	if e != nil && rc.ctx.Err() != nil {
+		r.canceled = true
+		return &r, fmt.Errorf("command %s canceled: %w", c.commandLine(), rc.ctx.Err())
+	}
+	if e != nil && ctx.Err() != nil {
+		r.timedOut = true
+		return &r, fmt.Errorf("command timed out after %v", c.timeout)
+	}
+	if ee, ok := e.(*exec.ExitError); ok {
+		r.code = ee.ExitCode()
+		if ee.Exited() {
+			e = nil
 		} else {
-			// Again not using t.Error
-			t.Errorf("error output:\n%s", err.String())
+			r.signaled = true
+			r.signal = signalDescription(ee.ProcessState)
+			if r.signal != "" {
+				e = fmt.Errorf("command terminated by signal: %s", r.signal)
+			} else {
+				e = fmt.Errorf("command terminated abnormally: %w", e)
+			}
 		}
-		t.Errorf("exit code: %d", code)
-		t.FailNow()
 	}
+	return &r, e
+}
+
+// Type throttledReader passes reads through to another Reader,
+// limiting the rate at which data is returned.
+type throttledReader struct {
+	r     io.Reader
+	rate  int // bytes per second
+	start time.Time
+	n     int64 // bytes read so far
+}
+
+// Method Read reads from the underlying Reader, in small pieces, sleeping as needed
+// so that no more than rate bytes per second are returned on average.
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	if tr.start.IsZero() {
+		tr.start = time.Now()
+	}
+	// Read in small pieces, so the data arrives steadily rather than in bursts.
+	if max := tr.rate/10 + 1; len(p) > max {
+		p = p[:max]
+	}
+	n, e := tr.r.Read(p)
+	tr.n += int64(n)
+	due := tr.start.Add(time.Duration(tr.n) * time.Second / time.Duration(tr.rate))
+	time.Sleep(time.Until(due))
+	return n, e
+}
+
+// Type generatedReader produces a fixed amount of generated text,
+// in lines of 64 bytes each, including the newline.
+type generatedReader struct {
+	offset, size int64
+}
+
+// Constant generatedLine is the text from which generatedReader builds its lines.
+const generatedLine = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ-\n"
+
+// Method Read fills p with the next part of the generated text,
+// returning io.EOF once size bytes have been produced.
+func (gr *generatedReader) Read(p []byte) (int, error) {
+	remaining := gr.size - gr.offset
+	if remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	for i := range p {
+		p[i] = generatedLine[(gr.offset+int64(i))%int64(len(generatedLine))]
+	}
+	gr.offset += int64(len(p))
+	return len(p), nil
+}
+
+// Constant streamGap is the minimum time between writes for
+// a chunkWriter to consider them parts of different chunks.
+const streamGap = 10 * time.Millisecond
+
+// Type chunkWriter passes writes through to another Writer, and counts the chunks
+// written; a chunk is a series of writes, each following closely on the previous one.
+type chunkWriter struct {
+	w      io.Writer
+	last   time.Time
+	chunks int
+}
+
+// Method Write passes p to the underlying Writer, counting a new chunk
+// unless the previous write was less than streamGap ago.
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	now := time.Now()
+	if cw.chunks == 0 || now.Sub(cw.last) >= streamGap {
+		cw.chunks++
+	}
+	cw.last = now
+	return cw.w.Write(p)
+}
+
+// Function splitLines splits text into lines, omitting the newline characters.
+// A final newline does not begin a new line.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Function standardCode reports whether code is in the range 0-255.
+//
+// A process that exits normally on Unix can only report a code in this range,
+// so report notes other values there; see exitCodesLimited.
+func standardCode(code int) bool {
+	return code >= 0 && code <= 255
 }
@@ -4,13 +4,19 @@
 package gotest
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"math/big"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestCmdDefaults(t *testing.T) {
@@ -20,50 +26,54 @@ func TestCmdDefaults(t *testing.T) {
 
 	st.Reset()
 	Command("/bin/false").Run(&st, "")
-	st.Expect(t, true, true, `non-zero exit code
+	expectReport(t, &st, true, true, `non-zero exit code
 command: /bin/false
 no input
 no output
 no error output
 exit code: 1
+duration: D
 `)
 
 	st.Reset()
 	Command("/bin/printf", "99").Run(&st, "")
-	st.Expect(t, true, true, `unexpected output
+	expectReport(t, &st, true, true, `unexpected output
 command: /bin/printf 99
 no input
 output:
 99
 no error output
 exit code: 0
+duration: D
 `)
 
 	st.Reset()
 	Command("/bin/sh", "-c", "echo 87 >&2").Run(&st, "")
-	st.Expect(t, true, true, `unexpected error output
+	expectReport(t, &st, true, true, `unexpected error output
 command: /bin/sh -c echo 87 >&2
 no input
 no output
 error output:
 87
 exit code: 0
+duration: D
 `)
 
 	st.Reset()
 	Command("/bin/sh", "-c", "echo 87 >&2; exit 3").Run(&st, "")
-	st.Expect(t, true, true, `unexpected error output
+	expectReport(t, &st, true, true, `unexpected error output
 command: /bin/sh -c echo 87 >&2; exit 3
 no input
 no output
 error output:
 87
 exit code: 3
+duration: D
 `)
 
 	st.Reset()
 	Command("/bin/sh", "-c", "echo 99; echo 87 >&2; exit 3").Run(&st, "")
-	st.Expect(t, true, true, `unexpected output
+	expectReport(t, &st, true, true, `unexpected output
 unexpected error output
 command: /bin/sh -c echo 99; echo 87 >&2; exit 3
 no input
@@ -72,17 +82,19 @@ output:
 error output:
 87
 exit code: 3
+duration: D
 `)
 
 	st.Reset()
 	Command("/bin/sh", "-c", "echo 99; exit 3").Run(&st, "")
-	st.Expect(t, true, true, `unexpected output
+	expectReport(t, &st, true, true, `unexpected output
 command: /bin/sh -c echo 99; exit 3
 no input
 output:
 99
 no error output
 exit code: 3
+duration: D
 `)
 }
 
@@ -96,14 +108,18 @@ func TestCmdOutput(t *testing.T) {
 
 	st.Reset()
 	c.Run(&st, "eight\n")
-	st.Expect(t, true, true, `incorrect output
+	expectReport(t, &st, true, true, `output differs from expected:
+@@ -1,1 +1,1 @@
+-a seven b
++a eight b
+incorrect output
 command: /bin/sh -c read x; echo a $x b
 input:
 eight
-output:
-a eight b
+output: (differences shown above)
 no error output
 exit code: 0
+duration: D
 `)
 
 	st.Reset()
@@ -115,7 +131,7 @@ exit code: 0
 
 	st.Reset()
 	c.Run(&st, "purple")
-	st.Expect(t, true, true, `incorrect output
+	expectReport(t, &st, true, true, `incorrect output
 command: /bin/sh -c read x; echo a $x b
 input:
 purple
@@ -123,12 +139,13 @@ output:
 a purple b
 no error output
 exit code: 0
+duration: D
 `)
 
 	st.Reset()
 	c.CheckStdout(nil)
 	c.Run(&st, "greenery")
-	st.Expect(t, true, true, `unexpected output
+	expectReport(t, &st, true, true, `unexpected output
 command: /bin/sh -c read x; echo a $x b
 input:
 greenery
@@ -136,6 +153,7 @@ output:
 a greenery b
 no error output
 exit code: 0
+duration: D
 `)
 }
 
@@ -149,7 +167,7 @@ func TestCmdError(t *testing.T) {
 
 	st.Reset()
 	c.Run(&st, "chill")
-	st.Expect(t, true, true, `incorrect error output
+	expectReport(t, &st, true, true, `incorrect error output
 command: /bin/sh -c read x; if [ "$x" != nothing ]; then echo $x >&2; exit 99; fi
 input:
 chill
@@ -157,6 +175,7 @@ no output
 error output:
 chill
 exit code: 99
+duration: D
 `)
 
 	st.Reset()
@@ -168,7 +187,7 @@ exit code: 99
 
 	st.Reset()
 	c.Run(&st, "apples grow in England")
-	st.Expect(t, true, true, `incorrect error output
+	expectReport(t, &st, true, true, `incorrect error output
 command: /bin/sh -c read x; if [ "$x" != nothing ]; then echo $x >&2; exit 99; fi
 input:
 apples grow in England
@@ -176,12 +195,13 @@ no output
 error output:
 apples grow in England
 exit code: 99
+duration: D
 `)
 
 	st.Reset()
 	c.CheckStderr(nil)
 	c.Run(&st, "tropical")
-	st.Expect(t, true, true, `unexpected error output
+	expectReport(t, &st, true, true, `unexpected error output
 command: /bin/sh -c read x; if [ "$x" != nothing ]; then echo $x >&2; exit 99; fi
 input:
 tropical
@@ -189,6 +209,7 @@ no output
 error output:
 tropical
 exit code: 99
+duration: D
 `)
 
 	st.Reset()
@@ -206,24 +227,26 @@ func TestCmdCode(t *testing.T) {
 
 	st.Reset()
 	c.Run(&st, "31")
-	st.Expect(t, true, true, `incorrect exit code
+	expectReport(t, &st, true, true, `incorrect exit code
 command: /bin/sh -c read x; exit $x
 input:
 31
 no output
 no error output
 exit code: 31
+duration: D
 `)
 
 	st.Reset()
 	c.Run(&st, "0")
-	st.Expect(t, true, true, `incorrect exit code
+	expectReport(t, &st, true, true, `incorrect exit code
 command: /bin/sh -c read x; exit $x
 input:
 0
 no output
 no error output
 exit code: 0
+duration: D
 `)
 
 	st.Reset()
@@ -245,7 +268,7 @@ exit code: 0
 
 	st.Reset()
 	c.Run(&st, "99")
-	st.Expect(t, true, true, `99 is not prime
+	expectReport(t, &st, true, true, `99 is not prime
 incorrect exit code
 command: /bin/sh -c read x; exit $x
 input:
@@ -253,6 +276,7 @@ input:
 no output
 no error output
 exit code: 99
+duration: D
 `)
 
 	st.Reset()
@@ -262,13 +286,14 @@ exit code: 99
 
 	st.Reset()
 	c.Run(&st, "1")
-	st.Expect(t, true, true, `non-zero exit code
+	expectReport(t, &st, true, true, `non-zero exit code
 command: /bin/sh -c read x; exit $x
 input:
 1
 no output
 no error output
 exit code: 1
+duration: D
 `)
 
 	st.Reset()
@@ -279,7 +304,7 @@ exit code: 1
 
 	st.Reset()
 	c2.Run(&st, "0")
-	st.Expect(t, true, true, `error output produced but exit code was 0
+	expectReport(t, &st, true, true, `error output produced but exit code was 0
 command: /bin/sh -c echo oops >&2; read x; exit $x
 input:
 0
@@ -287,12 +312,13 @@ no output
 error output:
 oops
 exit code: 0
+duration: D
 `)
 
 	st.Reset()
 	c2.WantStderr("hunky dory\n")
 	c2.Run(&st, "0")
-	st.Expect(t, true, true, `incorrect error output
+	expectReport(t, &st, true, true, `incorrect error output
 command: /bin/sh -c echo oops >&2; read x; exit $x
 input:
 0
@@ -300,12 +326,16 @@ no output
 error output:
 oops
 exit code: 0
+duration: D
 `)
 
 	st.Reset()
 	c2.WantStdout("erewhon\n")
 	c2.Run(&st, "0")
-	st.Expect(t, true, true, `incorrect output
+	expectReport(t, &st, true, true, `output differs from expected:
+@@ -1,1 +0,0 @@
+-erewhon
+incorrect output
 incorrect error output
 command: /bin/sh -c echo oops >&2; read x; exit $x
 input:
@@ -314,12 +344,16 @@ no output
 error output:
 oops
 exit code: 0
+duration: D
 `)
 
 	st.Reset()
 	c2.WantStderr("oops\n")
 	c2.Run(&st, "0")
-	st.Expect(t, true, true, `incorrect output
+	expectReport(t, &st, true, true, `output differs from expected:
+@@ -1,1 +0,0 @@
+-erewhon
+incorrect output
 command: /bin/sh -c echo oops >&2; read x; exit $x
 input:
 0
@@ -327,6 +361,7 @@ no output
 error output:
 oops
 exit code: 0
+duration: D
 `)
 }
 
@@ -375,3 +410,1525 @@ func TestCmdChdir(t *testing.T) {
 		t.Error("bad error message for non-existent directory:", st.Logged())
 	}
 }
+
+func TestCmdCaptureInto(t *testing.T) {
+	var out, err bytes.Buffer
+	out.WriteString("stale")
+	c := Command("/bin/sh", "-c", "read x; echo out $x; echo err $x >&2")
+	c.CaptureInto(&out, &err)
+	c.WantStdout("out one\n")
+	c.WantStderr("err one\n")
+	c.WantCode(0)
+	c.Run(t, "one")
+	Expect(t, "out one\n", out.String())
+	Expect(t, "err one\n", err.String())
+
+	var st StubReporter
+	c.Run(&st, "two")
+	expectReport(t, &st, true, true, `output differs from expected:
+@@ -1,1 +1,1 @@
+-out one
++out two
+incorrect output
+incorrect error output
+command: /bin/sh -c read x; echo out $x; echo err $x >&2
+input:
+two
+output: (differences shown above)
+error output:
+err two
+exit code: 0
+duration: D
+`)
+	Expect(t, "out two\n", out.String())
+	Expect(t, "err two\n", err.String())
+
+	var only bytes.Buffer
+	c.CaptureInto(nil, &only)
+	c.WantStdout("out three\n")
+	c.WantStderr("err three\n")
+	c.Run(t, "three")
+	Expect(t, "err three\n", only.String())
+	Expect(t, "out two\n", out.String())
+}
+
+func TestStandardCode(t *testing.T) {
+	Expect(t, true, standardCode(0))
+	Expect(t, true, standardCode(255))
+	Expect(t, false, standardCode(256))
+	Expect(t, false, standardCode(-1))
+}
+
+func TestCmdCapture(t *testing.T) {
+	c := Command("/bin/sh", "-c", "read x; echo out $x; echo err $x >&2; exit 4")
+	c.WantStdout("ignored")
+	out, errOut, code, e := c.Capture(t, "one")
+	Expect(t, "out one\n", out)
+	Expect(t, "err one\n", errOut)
+	Expect(t, 4, code)
+	Require(t, e == nil)
+
+	var st StubReporter
+	c = Command("/bin/sh", "-c", "kill -9 $$")
+	out, errOut, code, e = c.Capture(&st, "")
+	Require(t, e != nil)
+	Expect(t, "", out)
+	Expect(t, "", errOut)
+	st.Expect(t, true, true, e.Error()+"\n")
+
+	st.Reset()
+	c = Command(filepath.Join(t.TempDir(), "nonexistent"))
+	_, _, _, e = c.Capture(&st, "")
+	Require(t, e != nil)
+	st.Expect(t, true, true, e.Error()+"\n")
+}
+
+func TestCmdStreaming(t *testing.T) {
+	c := Command("/bin/sh", "-c", "echo a; sleep 0.1; echo b; sleep 0.1; echo c")
+	c.WantStdout("a\nb\nc\n")
+	c.WantStreaming(3)
+	c.Run(t, "")
+
+	var st StubReporter
+	c.WantStreaming(4)
+	c.Run(&st, "")
+	expectReport(t, &st, true, true, `output arrived in 3 chunk(s); expected at least 4
+command: /bin/sh -c echo a; sleep 0.1; echo b; sleep 0.1; echo c
+no input
+output:
+a
+b
+c
+no error output
+exit code: 0
+duration: D
+`)
+
+	st.Reset()
+	c = Command("/bin/sh", "-c", "sleep 0.1; echo a b c")
+	c.WantStdout("a b c\n")
+	c.WantStreaming(2)
+	c.Run(&st, "")
+	Expect(t, true, st.Killed())
+	Require(t, strings.HasPrefix(st.Logged(), "output arrived in 1 chunk(s); expected at least 2\n"))
+
+	st.Reset()
+	c.WantStreaming(0)
+	c.Run(&st, "")
+	st.Expect(t, false, false, "")
+}
+
+func TestCmdRunWith(t *testing.T) {
+	tmp := t.TempDir()
+	c := Command("/bin/sh", "-c", `read x; echo "$x $GOTEST_A $GOTEST_B"; pwd`)
+
+	c.WantStdout("in 1 2\n" + tmp + "\n")
+	c.RunWith(t, WithInput("in"), WithEnv("GOTEST_A=0", "GOTEST_B=2"), WithEnv("GOTEST_A=1"), WithDir(tmp))
+
+	wd, e := os.Getwd()
+	if e != nil {
+		t.Fatal(e)
+	}
+	c.WantStdout("  \n" + wd + "\n")
+	c.RunWith(t)
+	c.Run(t, "")
+
+	sub := filepath.Join(tmp, "sub")
+	if e := os.Mkdir(sub, 0777); e != nil {
+		t.Fatal(e)
+	}
+	c.Chdir(tmp)
+	c.WantStdout("  \n" + sub + "\n")
+	c.RunWith(t, WithDir(sub))
+
+	var st StubReporter
+	c.WantStdout("")
+	c.RunWith(&st, WithInput("x\n"), WithEnv("GOTEST_B=b"))
+	expectReport(t, &st, true, true, `output differs from expected:
+@@ -0,0 +1,2 @@
++x  b
++`+tmp+`
+incorrect output
+command: /bin/sh -c read x; echo "$x $GOTEST_A $GOTEST_B"; pwd
+input:
+x
+output: (differences shown above)
+no error output
+exit code: 0
+duration: D
+`)
+}
+
+func TestCmdShell(t *testing.T) {
+	c := Shell("read x; echo $x | tr a b")
+	c.WantStdout("bbb\n")
+	c.Run(t, "aaa")
+
+	c = Shell("exit 3")
+	c.WantCode(3)
+	c.Run(t, "")
+
+	c = Shell("false | true")
+	if e := exec.Command("/bin/sh", "-c", "set -o pipefail").Run(); e == nil {
+		c.WantCode(1)
+	} else {
+		c.WantCode(0)
+	}
+	c.Run(t, "")
+}
+
+func TestShellCommand(t *testing.T) {
+	c := ShellCommand("echo hello")
+	c.WantStdoutForOS(map[string]string{"windows": "hello\r\n", "default": "hello\n"})
+	c.Run(t, "")
+
+	c = ShellCommand("exit 3")
+	c.WantCode(3)
+	c.Run(t, "")
+
+	if runtime.GOOS != "windows" {
+		Expect(t, Shell("true").commandLine(), ShellCommand("true").commandLine())
+	}
+}
+
+func TestRequireCommand(t *testing.T) {
+	path := RequireCommand(t, "sh")
+	Require(t, filepath.IsAbs(path))
+	Command(path, "-c", "exit 0").Run(t, "")
+
+	var st StubReporter
+	path = RequireCommand(&st, "gotest-no-such-command")
+	Expect(t, "", path)
+	Expect(t, true, st.Skipped())
+	Expect(t, false, st.Failed())
+	Require(t, strings.HasPrefix(st.SkipReason(), "command gotest-no-such-command not available: "))
+}
+
+func TestCmdStdoutForOS(t *testing.T) {
+	c := Command("/bin/printf", "here")
+	c.WantStdoutForOS(map[string]string{runtime.GOOS: "here", "default": "elsewhere"})
+	c.Run(t, "")
+
+	c.WantStdoutForOS(map[string]string{"default": "here"})
+	c.Run(t, "")
+
+	var st StubReporter
+	c.WantStdoutForOS(map[string]string{runtime.GOOS: "there", "default": "here"})
+	c.Run(&st, "")
+	Expect(t, true, st.Killed())
+	Require(t, strings.HasPrefix(st.Logged(), "output differs from expected:\n@@ -1,1 +1,1 @@\n-there (no newline at end)\n+here (no newline at end)\nincorrect output\n"))
+
+	c.WantStdoutForOS(map[string]string{"plan10": "here", "no such os": "here"})
+	st.Reset()
+	c.Run(&st, "")
+	Expect(t, true, st.Killed())
+	Require(t, strings.HasPrefix(st.Logged(), "no expected output for GOOS "+runtime.GOOS+" and no default; keys present: no such os, plan10\nincorrect output\n"))
+}
+
+func TestCmdAllowStderrLines(t *testing.T) {
+	allowed := []string{"warning: old", "warning: slow"}
+	for _, warnings := range []string{"", `warning: old\n`, `warning: slow\nwarning: old\nwarning: slow\n`, "warning: old"} {
+		c := Command("/bin/sh", "-c", "printf '"+warnings+"' >&2; exit 1")
+		c.AllowStderrLines(allowed)
+		c.WantCode(1)
+		c.Run(t, "")
+	}
+
+	var st StubReporter
+	c := Command("/bin/sh", "-c", `printf 'warning: old\nerror: bad\nwarning: slow\n\n' >&2; exit 1`)
+	c.AllowStderrLines(allowed)
+	c.Run(&st, "")
+	expectReport(t, &st, true, true, `unexpected error output line: "error: bad"
+unexpected error output line: ""
+incorrect error output
+command: /bin/sh -c printf 'warning: old\nerror: bad\nwarning: slow\n\n' >&2; exit 1
+no input
+no output
+error output:
+warning: old
+error: bad
+warning: slow
+
+exit code: 1
+duration: D
+`)
+}
+
+func TestCmdWantFailure(t *testing.T) {
+	c := Command("/bin/sh", "-c", `read x; echo "invalid argument: $x" >&2; exit $x`)
+	c.WantFailure([]int{1, 2}, "invalid")
+	c.Run(t, "1")
+	c.Run(t, "2")
+
+	var st StubReporter
+	c.Run(&st, "3")
+	expectReport(t, &st, true, true, `exit code 3 is not one of [1 2]
+incorrect exit code
+command: /bin/sh -c read x; echo "invalid argument: $x" >&2; exit $x
+input:
+3
+no output
+error output:
+invalid argument: 3
+exit code: 3
+duration: D
+`)
+
+	st.Reset()
+	c.Run(&st, "0")
+	expectReport(t, &st, true, true, `exit code 0; expected failure
+incorrect exit code
+command: /bin/sh -c read x; echo "invalid argument: $x" >&2; exit $x
+input:
+0
+no output
+error output:
+invalid argument: 0
+exit code: 0
+duration: D
+`)
+
+	c.WantFailure(nil, "argument: 7")
+	c.Run(t, "7")
+
+	st.Reset()
+	c.Run(&st, "8")
+	expectReport(t, &st, true, true, `error output did not contain "argument: 7"
+incorrect error output
+command: /bin/sh -c read x; echo "invalid argument: $x" >&2; exit $x
+input:
+8
+no output
+error output:
+invalid argument: 8
+exit code: 8
+duration: D
+`)
+
+	c = Command("/bin/sh", "-c", "echo out; exit 1")
+	c.WantFailure(nil, "")
+	st.Reset()
+	c.Run(&st, "")
+	Expect(t, true, st.Killed())
+	Require(t, strings.HasPrefix(st.Logged(), "unexpected output\n"))
+
+	c.WantStdout("out\n")
+	c.Run(t, "")
+}
+
+func TestCmdNoExtraFds(t *testing.T) {
+	c := Command("/bin/sh", "-c", "cat; echo oops >&2; exit 1")
+	c.WantStdout("data")
+	c.WantStderr("oops\n")
+	c.WantNoExtraFds()
+	c.Run(t, "data")
+	c.Run(t, "data")
+
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	// Simulate a leak by opening a file while checking the output.
+	path := filepath.Join(t.TempDir(), "leak")
+	var leak *os.File
+	c.CheckStdout(func(actual string) bool {
+		var e error
+		leak, e = os.Create(path)
+		return e == nil
+	})
+	var st StubReporter
+	c.Run(&st, "data")
+	if leak == nil {
+		t.Fatal("file not created")
+	}
+	defer leak.Close()
+	expectReport(t, &st, true, true, fmt.Sprintf(`1 file descriptor(s) leaked
+fd %d: %s
+command: /bin/sh -c cat; echo oops >&2; exit 1
+input:
+data
+output:
+data
+error output:
+oops
+exit code: 1
+duration: D
+`, leak.Fd(), path))
+}
+
+func TestCmdThrottleStdin(t *testing.T) {
+	c := Command("/bin/cat")
+	c.WantStdout("0123456789012345678901234567890123456789")
+	c.ThrottleStdin(100)
+	start := time.Now()
+	c.Run(t, "0123456789012345678901234567890123456789")
+	if elapsed := time.Since(start); elapsed < 350*time.Millisecond {
+		t.Error("throttled input took only", elapsed)
+	}
+
+	var st StubReporter
+	c.ThrottleStdin(1000)
+	c.Run(&st, "abc")
+	expectReport(t, &st, true, true, `output differs from expected:
+@@ -1,1 +1,1 @@
+-0123456789012345678901234567890123456789 (no newline at end)
++abc (no newline at end)
+incorrect output
+command: /bin/cat
+input:
+abc
+input throttled to 1000 bytes/sec
+output: (differences shown above)
+no error output
+exit code: 0
+duration: D
+`)
+}
+
+func TestCmdStdoutSorted(t *testing.T) {
+	c := Command("/bin/cat")
+	c.WantStdoutSorted()
+	c.Run(t, "")
+	c.Run(t, "apple\nbanana\nbanana\ncherry\n")
+
+	var st StubReporter
+	c.Run(&st, "apple\ncherry\nbanana\n")
+	expectReport(t, &st, true, true, `output lines 2 and 3 are out of order: "cherry", "banana"
+incorrect output
+command: /bin/cat
+input:
+apple
+cherry
+banana
+output:
+apple
+cherry
+banana
+no error output
+exit code: 0
+duration: D
+`)
+
+	numeric := func(a, b string) int {
+		x, e1 := strconv.Atoi(a)
+		y, e2 := strconv.Atoi(b)
+		if e1 != nil || e2 != nil {
+			t.Fatal("non-numeric line")
+		}
+		return x - y
+	}
+	c.WantStdoutSortedFunc(numeric)
+	c.Run(t, "2\n10\n10\n300\n")
+
+	st.Reset()
+	c.Run(&st, "2\n10\n9")
+	Expect(t, true, st.Killed())
+	Require(t, strings.HasPrefix(st.Logged(), `output lines 2 and 3 are out of order: "10", "9"`+"\n"))
+}
+
+func TestCmdThroughput(t *testing.T) {
+	c := Command("/usr/bin/wc", "-c")
+	c.WantStdout("1000000\n")
+	c.WantThroughput(1e6, 10*time.Second)
+	c.Run(t, "")
+
+	c = Command("/usr/bin/head", "-n", "2")
+	c.WantStdout(generatedLine + generatedLine)
+	c.WantThroughput(1000, 10*time.Second)
+	c.Run(t, "")
+
+	var st StubReporter
+	c = Command("/bin/sh", "-c", "cat >/dev/null; sleep 0.1")
+	c.WantThroughput(100, time.Millisecond)
+	c.Run(&st, "")
+	Expect(t, true, st.Killed())
+	Require(t, strings.HasPrefix(st.Logged(), "processed 100 bytes in "))
+	Require(t, strings.Contains(st.Logged(), " MB/s); expected at most 1ms\ncommand: /bin/sh -c cat >/dev/null; sleep 0.1\ninput: 100 generated bytes\nno output\n"))
+
+	msg := MustPanic(t, func() {
+		c.Run(t, "input")
+	})
+	Expect(t, "gotest.Cmd: input given for a command that uses generated input", msg.(string))
+
+	MustPanicWith(t, "gotest.Cmd.WantThroughput: a time limit requires a positive number of bytes", func() {
+		c.WantThroughput(0, time.Second)
+	})
+	MustPanicWith(t, "gotest.Cmd.WantThroughput: negative argument", func() {
+		c.WantThroughput(-1, 0)
+	})
+	c.WantThroughput(0, 0)
+	c.Run(t, "")
+}
+
+func TestCmdEcho(t *testing.T) {
+	c := Command("/bin/cat")
+	c.WantEcho()
+	c.Run(t, "")
+	c.Run(t, "one\ntwo\n")
+	c.RunWith(t, WithInput("three"))
+
+	var st StubReporter
+	c = Command("/bin/sed", "s/two/2/")
+	c.WantEcho()
+	c.Run(&st, "one\ntwo\nthree\n")
+	expectReport(t, &st, true, true, `output differs from input:
+@@ -1,3 +1,3 @@
+ one
+-two
++2
+ three
+incorrect output
+command: /bin/sed s/two/2/
+input:
+one
+two
+three
+output: (differences shown above)
+no error output
+exit code: 0
+duration: D
+`)
+}
+
+func TestCmdFilesCreated(t *testing.T) {
+	tmp := t.TempDir()
+	c := Command("/bin/sh", "-c", "mkdir -p sub/empty; touch a sub/b")
+	c.Chdir(tmp)
+	c.WantFilesCreated("sub/b", "a")
+	c.Run(t, "")
+
+	var st StubReporter
+	c.WantFilesCreated("a", "c")
+	c.Run(&st, "")
+	expectReport(t, &st, true, true, `missing file: c
+unexpected file: sub/b
+command: /bin/sh -c mkdir -p sub/empty; touch a sub/b
+no input
+no output
+no error output
+exit code: 0
+duration: D
+`)
+
+	c = Command("/bin/true")
+	c.WantFilesCreated()
+	c.RunWith(t, WithDir(t.TempDir()))
+}
+
+func TestCmdExitCategory(t *testing.T) {
+	c := Command("/bin/sh", "-c", `read x; case $x in kill) kill -9 $$;; *) exit $x;; esac`)
+	c.WantExitCategory(Success)
+	c.Run(t, "0")
+	c.WantExitCategory(Failure)
+	c.Run(t, "1")
+	c.Run(t, "99")
+	c.WantExitCategory(Signal)
+	c.Run(t, "kill")
+
+	var st StubReporter
+	c.Run(&st, "3")
+	expectReport(t, &st, true, true, `exit category Failure; expected Signal
+command: /bin/sh -c read x; case $x in kill) kill -9 $$;; *) exit $x;; esac
+input:
+3
+no output
+no error output
+exit code: 3
+duration: D
+`)
+
+	st.Reset()
+	c.WantExitCategory(Success)
+	c.Run(&st, "kill")
+	expectReport(t, &st, true, true, `exit category Signal; expected Success
+command: /bin/sh -c read x; case $x in kill) kill -9 $$;; *) exit $x;; esac
+input:
+kill
+no output
+no error output
+exit code: -1
+terminated by signal: killed (SIGKILL)
+duration: D
+`)
+
+	// WantCode replaces the category check, so a signal is again fatal.
+	st.Reset()
+	c.WantCode(0)
+	c.Run(&st, "kill")
+	st.Expect(t, true, true, "command terminated by signal: killed (SIGKILL)\n")
+
+	Expect(t, "Success", Success.String())
+	Expect(t, "Failure", Failure.String())
+	Expect(t, "Signal", Signal.String())
+	Expect(t, "Timeout", Timeout.String())
+	Expect(t, "ExitCategory(0)", ExitCategory(0).String())
+}
+
+func TestEnvEchoCommand(t *testing.T) {
+	c := EnvEchoCommand("GOTEST_ECHO_1")
+	c.WantStdout("a value")
+	c.RunWith(t, WithEnv("GOTEST_ECHO_1=a value"))
+	c.WantStdout("")
+	c.Run(t, "")
+
+	for _, key := range []string{"", "1A", "A-B", "A B", "$X"} {
+		msg := MustPanic(t, func() {
+			EnvEchoCommand(key)
+		})
+		Expect(t, "gotest.EnvEchoCommand: invalid environment variable name "+strconv.Quote(key), msg.(string))
+	}
+}
+
+func TestCmdCodeNamed(t *testing.T) {
+	sysexits := map[string]int{"EX_OK": 0, "EX_USAGE": 64, "EX_DATAERR": 65}
+	c := Command("/bin/sh", "-c", "read x; exit $x")
+	c.WantCodeNamed("EX_USAGE", sysexits)
+	c.Run(t, "64")
+
+	var st StubReporter
+	c.Run(&st, "65")
+	expectReport(t, &st, true, true, `exit code 65 (EX_DATAERR); expected 64 (EX_USAGE)
+incorrect exit code
+command: /bin/sh -c read x; exit $x
+input:
+65
+no output
+no error output
+exit code: 65
+duration: D
+`)
+
+	st.Reset()
+	c.Run(&st, "3")
+	Require(t, strings.HasPrefix(st.Logged(), "exit code 3; expected 64 (EX_USAGE)\nincorrect exit code\n"))
+
+	msg := MustPanic(t, func() {
+		c.WantCodeNamed("EX_NOPE", sysexits)
+	})
+	Expect(t, "gotest.Cmd.WantCodeNamed: no exit code named EX_NOPE", msg.(string))
+}
+
+func TestCmdCleanSuccess(t *testing.T) {
+	c := Command("/bin/sh", "-c", `read out err code; echo $out; [ "$err" != - ] && echo $err >&2; exit $code`)
+	c.WantStdout("good\n")
+	c.WantCleanSuccess()
+	c.Run(t, "good - 0")
+	c.Run(t, "anything failure 1")
+
+	var st StubReporter
+	c.Run(&st, "good warning 0")
+	expectReport(t, &st, true, true, `error output produced but exit code was 0
+command: /bin/sh -c read out err code; echo $out; [ "$err" != - ] && echo $err >&2; exit $code
+input:
+good warning 0
+output:
+good
+error output:
+warning
+exit code: 0
+duration: D
+`)
+
+	st.Reset()
+	c.Run(&st, "bad - 0")
+	Require(t, strings.HasPrefix(st.Logged(), "output differs from expected:\n@@ -1,1 +1,1 @@\n-good\n+bad\nincorrect output\ncommand: "))
+
+	st.Reset()
+	c.Run(&st, "anything - 2")
+	Require(t, strings.HasPrefix(st.Logged(), "non-zero exit code but no error output\ncommand: "))
+
+	// Setting a check on the exit code cancels WantCleanSuccess.
+	st.Reset()
+	c.WantCode(1)
+	c.Run(&st, "good failure 1")
+	Require(t, strings.HasPrefix(st.Logged(), "unexpected error output\ncommand: "))
+}
+
+func TestCmdHandlesLargeInput(t *testing.T) {
+	c := Command("/usr/bin/wc", "-l")
+	c.WantHandlesLargeInput(64 << 20)
+	c.WantStdout("1048576\n")
+	c.Run(t, "")
+
+	var st StubReporter
+	c = Command("/bin/sh", "-c", "head -c 10 >/dev/null; echo too much >&2; exit 1")
+	c.WantHandlesLargeInput(1 << 20)
+	c.Run(&st, "")
+	log := st.Logged()
+	Expect(t, true, st.Killed())
+	Require(t, strings.HasPrefix(log, `unexpected error output
+incorrect exit code
+command: /bin/sh -c head -c 10 >/dev/null; echo too much >&2; exit 1
+input: 1048576 generated bytes, of which at most `))
+	Require(t, strings.Contains(log, ` were read
+no output
+error output:
+too much
+exit code: 1
+duration: `))
+}
+
+func TestCmdHandlesLargeInputNegative(t *testing.T) {
+	c := Command("/usr/bin/wc", "-l")
+	MustPanicWith(t, "gotest.Cmd.WantHandlesLargeInput: negative size", func() {
+		c.WantHandlesLargeInput(-1)
+	})
+}
+
+func TestCmdCombinedLineCount(t *testing.T) {
+	c := Command("/bin/sh", "-c", "echo a; echo b >&2; echo c; printf d >&2")
+	c.WantStdout("a\nc\n")
+	c.WantStderr("b\nd")
+	c.WantCode(0)
+	c.WantCombinedLineCount(4)
+	c.Run(t, "")
+
+	var st StubReporter
+	c.WantCombinedLineCount(3)
+	c.Run(&st, "")
+	expectReport(t, &st, true, true, `output and error output have 4 line(s) in total; expected 3
+command: /bin/sh -c echo a; echo b >&2; echo c; printf d >&2
+no input
+output:
+a
+c
+error output:
+b
+d
+exit code: 0
+duration: D
+`)
+}
+
+func TestCmdDeterministicAcrossEnv(t *testing.T) {
+	c := Command("/bin/sh", "-c", `read x; echo $x; echo "${GOTEST_MODE:-plain}"`)
+	c.WantDeterministicAcrossEnv(t, "in", nil)
+	c.WantDeterministicAcrossEnv(t, "in", [][]string{{"GOTEST_MODE=plain"}, {}, {"LANG=C", "TZ=UTC"}})
+
+	var st StubReporter
+	c.WantDeterministicAcrossEnv(&st, "in", [][]string{{"TZ=UTC"}, {"GOTEST_MODE=plain"}, {"GOTEST_MODE=fancy", "LANG=C"}})
+	st.Expect(t, true, true, `output with environment ["GOTEST_MODE=fancy" "LANG=C"] differs from output with environment ["TZ=UTC"]:
+@@ -1,2 +1,2 @@
+ in
+-plain
++fancy
+`)
+}
+
+func TestCmdCodeNot(t *testing.T) {
+	c := Command("/bin/sh", "-c", "read x; exit $x")
+	c.WantCodeNot(0)
+	c.Run(t, "1")
+	c.Run(t, "255")
+
+	var st StubReporter
+	c.Run(&st, "0")
+	expectReport(t, &st, true, true, `exit code 0; expected any code but 0
+incorrect exit code
+command: /bin/sh -c read x; exit $x
+input:
+0
+no output
+no error output
+exit code: 0
+duration: D
+`)
+}
+
+func TestCmdTimeout(t *testing.T) {
+	c := Command("/bin/sh", "-c", "sleep 0.3")
+	c.Timeout(2 * time.Second)
+	// Each run gets a fresh deadline.
+	for i := 0; i < 3; i++ {
+		c.Run(t, "")
+	}
+
+	c = Command("/bin/sh", "-c", "echo partial; echo oops >&2; sleep 10; echo done")
+	c.Timeout(200 * time.Millisecond)
+	var st StubReporter
+	start := time.Now()
+	c.Run(&st, "")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("timed out command ran for %v", elapsed)
+	}
+	expectReport(t, &st, true, true, `command timed out after 200ms
+command: /bin/sh -c echo partial; echo oops >&2; sleep 10; echo done
+no input
+output:
+partial
+error output:
+oops
+no exit code; the command was killed
+duration: D
+`)
+
+	st.Reset()
+	_, _, _, e := c.Capture(&st, "")
+	Require(t, e != nil)
+	st.Expect(t, true, true, "command timed out after 200ms\n")
+}
+
+// Function readFile returns the contents of a file, failing the test if it can not be read.
+func readFile(t *testing.T, path string) string {
+	data, e := os.ReadFile(path)
+	if e != nil {
+		t.Fatal(e)
+	}
+	return string(data)
+}
+
+func TestCmdWithConfigFile(t *testing.T) {
+	c := Command("/bin/cat")
+	path := c.WithConfigFile(t, "name = value\n")
+	c.AppendArgs(path)
+	c.WantStdout("name = value\n")
+	c.Run(t, "")
+
+	var st StubReporter
+	path = c.WithConfigFile(&st, "x")
+	st.Expect(t, false, false, "")
+	Expect(t, "x", readFile(t, path))
+	st.RunCleanup()
+	_, e := os.Stat(path)
+	Require(t, os.IsNotExist(e))
+}
+
+func TestCmdWantTimeout(t *testing.T) {
+	c := Command("/bin/sh", "-c", "read x; echo started; sleep $x")
+	c.Timeout(200 * time.Millisecond)
+	c.WantExitCategory(Timeout)
+	c.WantStdout("started\n")
+	c.Run(t, "10")
+	Expect(t, -1, c.LastCode())
+
+	var st StubReporter
+	c.Run(&st, "0")
+	expectReport(t, &st, true, true, `exit category Success; expected Timeout
+command: /bin/sh -c read x; echo started; sleep $x
+input:
+0
+output:
+started
+no error output
+exit code: 0
+duration: D
+`)
+
+	c.WantStdout("finished\n")
+	st.Reset()
+	c.Run(&st, "10")
+	expectReport(t, &st, true, true, `output differs from expected:
+@@ -1,1 +1,1 @@
+-finished
++started
+incorrect output
+command: /bin/sh -c read x; echo started; sleep $x
+input:
+10
+output: (differences shown above)
+no error output
+no exit code; the command was killed
+duration: D
+`)
+}
+
+func TestCmdStdoutNotMatch(t *testing.T) {
+	c := Command("/bin/cat")
+	c.WantStdoutNotMatch(`pass(word)?=\S+`)
+	c.Run(t, "")
+	c.Run(t, "user=bob\n")
+
+	var st StubReporter
+	c.Run(&st, "user=bob password=hunter2\n")
+	expectReport(t, &st, true, true, `output matches "pass(word)?=\\S+" at offset 9: "password=hunter2"
+incorrect output
+command: /bin/cat
+input:
+user=bob password=hunter2
+output:
+user=bob password=hunter2
+no error output
+exit code: 0
+duration: D
+`)
+
+	MustPanic(t, func() { c.WantStdoutNotMatch("(") })
+}
+
+func TestCmdRunContext(t *testing.T) {
+	c := Command("/bin/cat")
+	c.WantStdout("hello\n")
+	c.RunContext(context.Background(), t, "hello\n")
+
+	c = Command("/bin/sh", "-c", "sleep 10; echo done")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	var st StubReporter
+	start := time.Now()
+	c.RunContext(ctx, &st, "")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("canceled command ran for %v", elapsed)
+	}
+	st.Expect(t, true, true, "command /bin/sh -c sleep 10; echo done canceled: context deadline exceeded\n")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	st.Reset()
+	c.RunContext(ctx, &st, "")
+	st.Expect(t, true, true, "command /bin/sh -c sleep 10; echo done canceled: context canceled\n")
+}
+
+func TestCmdSetenv(t *testing.T) {
+	c := Command("/bin/sh", "-c", `echo "${GOTEST_A-unset} ${GOTEST_B-unset}"`)
+	c.WantStdout("unset unset\n")
+	c.Run(t, "")
+
+	c.Setenv("GOTEST_A", "first")
+	c.Setenv("GOTEST_B", "b")
+	c.Setenv("GOTEST_A", "a")
+	c.WantStdout("a b\n")
+	c.Run(t, "")
+	c.WantStdout("a override\n")
+	c.RunWith(t, WithEnv("GOTEST_B=override"))
+
+	var st StubReporter
+	c.WantStdout("")
+	c.Run(&st, "")
+	expectReport(t, &st, true, true, `output differs from expected:
+@@ -0,0 +1,1 @@
++a b
+incorrect output
+command: /bin/sh -c echo "${GOTEST_A-unset} ${GOTEST_B-unset}"
+environment: GOTEST_A=a
+environment: GOTEST_B=b
+no input
+output: (differences shown above)
+no error output
+exit code: 0
+duration: D
+`)
+
+	MustPanic(t, func() { c.Setenv("", "x") })
+	MustPanic(t, func() { c.Setenv("A=B", "x") })
+}
+
+func TestCmdClearEnv(t *testing.T) {
+	t.Setenv("GOTEST_INHERITED", "yes")
+	c := Command("/usr/bin/env")
+	c.ClearEnv()
+	c.Run(t, "")
+
+	c.Setenv("GOTEST_A", "a")
+	c.WantStdout("GOTEST_A=a\n")
+	c.Run(t, "")
+	c.WantStdout("GOTEST_A=a\nGOTEST_B=b\n")
+	c.RunWith(t, WithEnv("GOTEST_B=b"))
+}
+
+func TestCmdRunUsage(t *testing.T) {
+	script := `case "$1" in --help) echo "Usage: tool [options]";; -h) echo "usage: tool"; exit 2;; *) exit 1;; esac`
+	args := []string{"-c", script, "tool"}
+	c := Command("/bin/sh", args...)
+	c.RunUsage(t, "Usage:")
+	Expect(t, 3, len(args))
+	Expect(t, 0, c.LastCode())
+
+	// The command's arguments and checks are unchanged.
+	Expect(t, "/bin/sh -c "+script+" tool", c.commandLine())
+	c.WantCode(1)
+	c.Run(t, "")
+	c.RunUsage(t, "Usage:")
+	c.Run(t, "")
+
+	c = Command("/bin/sh", "-c", script, "tool")
+	c.UsageConvention("-h", 2)
+	c.RunUsage(t, "usage: tool")
+	Expect(t, 2, c.LastCode())
+
+	c = Command("/bin/sh", "-c", script, "tool")
+	c.UsageConvention("-h", 0)
+	var st StubReporter
+	c.RunUsage(&st, "Usage:")
+	expectReport(t, &st, true, true, `output did not contain "Usage:"
+incorrect output
+exit code 2; expected 0 after -h
+incorrect exit code
+command: /bin/sh -c `+script+` tool -h
+no input
+output:
+usage: tool
+no error output
+exit code: 2
+duration: D
+`)
+
+	// The run uses the command's environment.
+	c = Command("/bin/sh", "-c", `echo "$GOTEST_NAME usage"`)
+	c.Setenv("GOTEST_NAME", "tool")
+	c.RunUsage(t, "tool usage")
+}
+
+func TestCmdWantContains(t *testing.T) {
+	c := Command("/bin/sh", "-c", "echo the grass is green; echo warning: low light >&2; exit 1")
+	c.WantStdoutContains("green")
+	c.WantStderrContains("warning")
+	c.Run(t, "")
+
+	c.WantStdoutContains("blue")
+	c.WantStderrContains("error")
+	var st StubReporter
+	c.Run(&st, "")
+	expectReport(t, &st, true, true, `output did not contain "blue"
+incorrect output
+error output did not contain "error"
+incorrect error output
+command: /bin/sh -c echo the grass is green; echo warning: low light >&2; exit 1
+no input
+output:
+the grass is green
+error output:
+warning: low light
+exit code: 1
+duration: D
+`)
+}
+
+func TestCmdWantMatch(t *testing.T) {
+	c := Command("/bin/sh", "-c", "echo started pid $$; echo took 12ms >&2")
+	c.WantStdoutMatch(regexp.MustCompile(`^started pid \d+\n$`))
+	c.WantStderrMatch(regexp.MustCompile(`took \d+ms`))
+	c.WantCode(0)
+	c.Run(t, "")
+
+	c.WantStdoutMatch(regexp.MustCompile(`^stopped`))
+	c.WantStderrMatch(regexp.MustCompile(`took \d+s\b`))
+	var st StubReporter
+	c.Run(&st, "")
+	log := st.Logged()
+	Require(t, strings.HasPrefix(log, `output did not match "^stopped"
+incorrect output
+error output did not match "took \\d+s\\b"
+incorrect error output
+`))
+
+	MustPanic(t, func() { c.WantStdoutMatch(nil) })
+	MustPanic(t, func() { c.WantStderrMatch(nil) })
+}
+
+func TestCmdCgroupLimitNegative(t *testing.T) {
+	c := Command("/bin/true")
+	MustPanic(t, func() { c.CgroupLimit(-1, 0) })
+	MustPanic(t, func() { c.CgroupLimit(0, -0.5) })
+}
+
+func TestCmdRunCapture(t *testing.T) {
+	c := Command("/bin/sh", "-c", `printf '\211PNG\r\n'; echo note >&2; exit 3`)
+	c.CheckStdout(func(string) bool { return true })
+	c.CheckStderr(func(string) bool { return true })
+	c.WantCode(3)
+	stdout, stderr, code := c.RunCapture(t, "")
+	Expect(t, "\x89PNG\r\n", string(stdout))
+	Expect(t, "note\n", string(stderr))
+	Expect(t, 3, code)
+
+	c.WantCode(0)
+	var st StubReporter
+	stdout, _, code = c.RunCapture(NotFatal{&st}, "")
+	Expect(t, true, st.Failed())
+	Expect(t, false, st.Killed())
+	Expect(t, "\x89PNG\r\n", string(stdout))
+	Expect(t, 3, code)
+
+	c = Command(filepath.Join(t.TempDir(), "nonexistent"))
+	st.Reset()
+	stdout, stderr, code = c.RunCapture(&st, "")
+	Expect(t, true, st.Killed())
+	Require(t, stdout == nil && stderr == nil)
+	Expect(t, -1, code)
+
+	c = Command("/bin/sh", "-c", "echo partial; sleep 10")
+	c.Timeout(100 * time.Millisecond)
+	st.Reset()
+	stdout, _, code = c.RunCapture(NotFatal{&st}, "")
+	Expect(t, true, st.Failed())
+	Expect(t, "partial\n", string(stdout))
+	Expect(t, -1, code)
+}
+
+func TestCmdCombined(t *testing.T) {
+	c := Command("/bin/sh", "-c", "echo one; echo two >&2; echo three; echo four >&2")
+	c.WantStdout("ignored")
+	c.WantCombined("one\ntwo\nthree\nfour\n")
+	c.Run(t, "")
+
+	c.CheckCombined(func(actual string) bool { return strings.HasPrefix(actual, "one\ntwo\n") })
+	c.Run(t, "")
+
+	var st StubReporter
+	c.WantCombined("one\nthree\ntwo\nfour\n")
+	c.Run(&st, "")
+	expectReport(t, &st, true, true, `incorrect combined output
+command: /bin/sh -c echo one; echo two >&2; echo three; echo four >&2
+no input
+combined output:
+one
+two
+three
+four
+exit code: 0
+duration: D
+`)
+
+	c.CheckCombined(nil)
+	c.WantStdout("one\nthree\n")
+	c.WantStderr("two\nfour\n")
+	c.WantCode(0)
+	c.Run(t, "")
+}
+
+func TestCmdRunReader(t *testing.T) {
+	c := Command("/usr/bin/wc", "-c")
+	c.WantStdout("10000000\n")
+	c.RunReader(t, &generatedReader{size: 10000000})
+
+	var st StubReporter
+	c.RunReader(&st, strings.NewReader("abc"))
+	expectReport(t, &st, true, true, `output differs from expected:
+@@ -1,1 +1,1 @@
+-10000000
++3
+incorrect output
+command: /usr/bin/wc -c
+input: (from io.Reader)
+output: (differences shown above)
+no error output
+exit code: 0
+duration: D
+`)
+}
+
+func TestCmdRunFile(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "input")
+	if e := os.WriteFile(input, []byte("one\ntwo\n"), 0o666); e != nil {
+		t.Fatal(e)
+	}
+
+	c := Command("/bin/cat")
+	c.WantStdout("one\ntwo\n")
+	c.RunFile(t, input)
+
+	var st StubReporter
+	c = Command("/usr/bin/wc", "-l")
+	c.WantStdout("3\n")
+	c.RunFile(&st, input)
+	expectReport(t, &st, true, true, `output differs from expected:
+@@ -1,1 +1,1 @@
+-3
++2
+incorrect output
+command: /usr/bin/wc -l
+input: (from file `+input+`)
+output: (differences shown above)
+no error output
+exit code: 0
+duration: D
+`)
+
+	st.Reset()
+	missing := filepath.Join(t.TempDir(), "missing")
+	c.RunFile(&st, missing)
+	Expect(t, true, st.Killed())
+	Require(t, strings.HasPrefix(st.Logged(), "cannot open input file "+missing+": "))
+	Expect(t, -1, c.LastCode())
+}
+
+func TestCmdWantStdoutFile(t *testing.T) {
+	golden := filepath.Join(t.TempDir(), "out.golden")
+	if e := os.WriteFile(golden, []byte("a\nb\nc\n"), 0o666); e != nil {
+		t.Fatal(e)
+	}
+
+	c := Command("/bin/cat")
+	c.WantStdoutFile(t, golden)
+	c.Run(t, "a\nb\nc\n")
+
+	var st StubReporter
+	c.Run(&st, "a\nB\nc\n")
+	expectReport(t, &st, true, true, `output differs from `+golden+`:
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+incorrect output
+command: /bin/cat
+input:
+a
+B
+c
+output: (differences shown above)
+no error output
+exit code: 0
+duration: D
+`)
+
+	// A small change to a large golden file produces a small report.
+	lines := numberedLines(10000)
+	lines[4999] = "changed\n"
+	if e := os.WriteFile(golden, []byte(strings.Join(lines, "")), 0o666); e != nil {
+		t.Fatal(e)
+	}
+	seq := Command("/usr/bin/seq", "10000")
+	seq.WantStdoutFile(t, golden)
+	st.Reset()
+	seq.Run(&st, "")
+	expectReport(t, &st, true, true, `output differs from `+golden+`:
+@@ -4997,7 +4997,7 @@
+ 4997
+ 4998
+ 4999
+-changed
++5000
+ 5001
+ 5002
+ 5003
+incorrect output
+command: /usr/bin/seq 10000
+no input
+output: (differences shown above)
+no error output
+exit code: 0
+duration: D
+`)
+
+	st.Reset()
+	missing := filepath.Join(t.TempDir(), "missing.golden")
+	c.WantStdoutFile(&st, missing)
+	Expect(t, true, st.Killed())
+
+	defer func(old bool) { UpdateGolden = old }(UpdateGolden)
+	UpdateGolden = true
+	c.WantStdoutFile(t, missing)
+	c.Run(t, "new\n")
+	data, e := os.ReadFile(missing)
+	if e != nil {
+		t.Fatal(e)
+	}
+	Expect(t, "new\n", string(data))
+}
+
+func TestCmdWantStdoutLine(t *testing.T) {
+	c := Command("/bin/cat")
+	c.WantStdoutLine("hello")
+	c.Run(t, "hello\n")
+	c.Run(t, "hello")
+
+	var st StubReporter
+	c.Run(&st, "hello\n\n")
+	expectReport(t, &st, true, true, `output differs from expected:
+@@ -1,1 +1,2 @@
+ hello
++
+incorrect output
+command: /bin/cat
+input:
+hello
+
+output: (differences shown above)
+no error output
+exit code: 0
+duration: D
+`)
+}
+
+func TestCmdTrimSpace(t *testing.T) {
+	c := Command("/bin/sh", "-c", `read -r out err; printf "  $out\n\n"; printf "$err" >&2`)
+	c.TrimSpace(true)
+	c.WantStdout("ok")
+	c.Run(t, "ok \\n")
+
+	var st StubReporter
+	c.WantStdout("ok\n")
+	c.Run(&st, "ok \\n")
+	Require(t, strings.HasPrefix(st.Logged(), "output differs from expected:\n@@ -1,1 +1,1 @@\n-ok\n+ok (no newline at end)\nincorrect output\n"))
+
+	c.TrimSpace(false)
+	c.WantStdout("  ok\n\n")
+	c.Run(t, "ok")
+	st.Reset()
+	c.Run(&st, "ok \\n")
+	Require(t, strings.HasPrefix(st.Logged(), "unexpected error output\n"))
+}
+
+func TestCmdArgs(t *testing.T) {
+	base := []string{"-c", `echo "$@"`, "sh", "--verbose"}
+	c := Command("/bin/sh", base...)
+	c.WantStdout("--verbose\n")
+	c.Run(t, "")
+
+	for _, file := range []string{"a", "b"} {
+		c.SetArgs(base...)
+		c.AppendArgs("sub", file)
+		c.WantStdout("--verbose sub " + file + "\n")
+		c.Run(t, "")
+	}
+	Expect(t, "--verbose", base[3])
+
+	var st StubReporter
+	c.AppendArgs("extra")
+	c.Run(&st, "")
+	Require(t, strings.Contains(st.Logged(), "\ncommand: /bin/sh -c echo \"$@\" sh --verbose sub b extra\n"))
+}
+
+func TestCmdLastCode(t *testing.T) {
+	c := Command("/bin/sh", "-c", "read x; exit $x")
+	Expect(t, -1, c.LastCode())
+	c.CheckCode(func(actual int) bool { return actual < 10 })
+	c.Run(t, "7")
+	Expect(t, 7, c.LastCode())
+	c.Run(t, "0")
+	Expect(t, 0, c.LastCode())
+
+	var st StubReporter
+	c.Run(&st, "12")
+	Expect(t, true, st.Killed())
+	Expect(t, 12, c.LastCode())
+
+	c.SetArgs("-c", "sleep 5; exit 1")
+	c.Timeout(100 * time.Millisecond)
+	st.Reset()
+	c.Run(&st, "")
+	Expect(t, true, st.Killed())
+	Expect(t, -1, c.LastCode())
+}
+
+func TestCmdWantSuccess(t *testing.T) {
+	c := Command("/bin/sh", "-c", "read x; echo warning >&2; exit $x")
+	c.WantStderr("warning\n")
+	c.WantSuccess()
+	c.Run(t, "0")
+
+	var st StubReporter
+	c.Run(&st, "4")
+	expectReport(t, &st, true, true, `exit code 4; expected 0
+incorrect exit code
+command: /bin/sh -c read x; echo warning >&2; exit $x
+input:
+4
+no output
+error output:
+warning
+exit code: 4
+duration: D
+`)
+
+	c.WantFailure(nil, "")
+	c.Run(t, "4")
+	st.Reset()
+	c.Run(&st, "0")
+	Require(t, strings.HasPrefix(st.Logged(), "exit code 0; expected failure\nincorrect exit code\n"))
+}
+
+func TestCmdWantAnyFailure(t *testing.T) {
+	c := Command("/bin/sh", "-c", "read x; echo warning >&2; exit $x")
+	c.WantStderr("warning\n")
+	c.WantAnyFailure()
+	c.Run(t, "3")
+
+	var st StubReporter
+	c.Run(&st, "0")
+	expectReport(t, &st, true, true, `exit code 0; expected failure
+incorrect exit code
+command: /bin/sh -c read x; echo warning >&2; exit $x
+input:
+0
+no output
+error output:
+warning
+exit code: 0
+duration: D
+`)
+
+	// The check on the error output is kept.
+	c.WantStderr("other\n")
+	st.Reset()
+	c.Run(&st, "3")
+	Require(t, strings.HasPrefix(st.Logged(), "incorrect error output\n"))
+}
+
+func TestCmdCheckReport(t *testing.T) {
+	c := Command("/bin/sh", "-c", "read x; echo $x; echo $x >&2; exit $x")
+	c.CheckStdoutReport(func(t Reporter, actual string) bool {
+		if actual == "99\n" {
+			t.Errorf("output line 1 is %q", "99")
+			return false
+		}
+		return true
+	})
+	c.CheckStderrReport(func(t Reporter, actual string) bool {
+		return actual != ""
+	})
+	c.CheckCodeReport(func(t Reporter, actual int) bool {
+		if big.NewInt(int64(actual)).ProbablyPrime(0) {
+			return true
+		}
+		t.Errorf("%d is not prime", actual)
+		return false
+	})
+	c.Run(t, "17")
+
+	var st StubReporter
+	c.Run(&st, "99")
+	expectReport(t, &st, true, true, `output line 1 is "99"
+incorrect output
+99 is not prime
+incorrect exit code
+command: /bin/sh -c read x; echo $x; echo $x >&2; exit $x
+input:
+99
+output:
+99
+error output:
+99
+exit code: 99
+duration: D
+`)
+}
+
+func TestCmdWantEmpty(t *testing.T) {
+	c := Command("/bin/sh", "-c", "read out err; echo $out; echo $err >&2")
+	c.WantStdout("x\n")
+	c.WantStderr("y\n")
+	c.WantCode(0)
+	c.Run(t, "x y")
+
+	c.WantStdoutEmpty()
+	c.WantStderrEmpty()
+	var st StubReporter
+	c.Run(&st, "x y")
+	Require(t, strings.HasPrefix(st.Logged(), `expected no output
+incorrect output
+expected no error output
+incorrect error output
+command: `))
+
+	c.SetArgs("-c", "true")
+	c.Run(t, "")
+}
+
+func TestCmdAlternateRunAndRunReader(t *testing.T) {
+	c := Command("/usr/bin/wc", "-c")
+	c.WantStdout("3\n")
+	c.Run(t, "abc")
+	c.RunReader(t, strings.NewReader("def"))
+	c.Run(t, "ghi")
+
+	var st StubReporter
+	c.RunReader(&st, strings.NewReader("long"))
+	Require(t, strings.Contains(st.Logged(), "\ninput: (from io.Reader)\n"))
+	st.Reset()
+	c.Run(&st, "long")
+	Require(t, strings.Contains(st.Logged(), "\ninput:\nlong\n"))
+
+	c = Command("/bin/cat")
+	c.WantEcho()
+	c.Run(t, "one\n")
+	st.Reset()
+	c.RunReader(&st, strings.NewReader("two\n"))
+	Require(t, strings.HasPrefix(st.Logged(), "output can not be compared to input that was not retained\n"))
+	c.Run(t, "three\n")
+}
+
+func TestCmdRunPiped(t *testing.T) {
+	producer := Command("/bin/sh", "-c", "read n; seq $n")
+	producer.CheckStdout(func(actual string) bool { return actual != "" })
+	consumer := Command("/usr/bin/wc", "-l")
+	consumer.WantStdout("3\n")
+	consumer.RunPiped(t, producer, "3")
+
+	var st StubReporter
+	consumer.RunPiped(&st, producer, "4")
+	expectReport(t, &st, true, true, `output differs from expected:
+@@ -1,1 +1,1 @@
+-3
++4
+incorrect output
+command: /usr/bin/wc -l
+input:
+1
+2
+3
+4
+output: (differences shown above)
+no error output
+exit code: 0
+duration: D
+pipeline failed in consumer
+`)
+
+	st.Reset()
+	consumer.RunPiped(&st, producer, "0")
+	expectReport(t, &st, true, true, `incorrect output
+command: /bin/sh -c read n; seq $n
+input:
+0
+no output
+no error output
+exit code: 0
+duration: D
+pipeline failed in producer
+`)
+
+	st.Reset()
+	consumer.RunPiped(&st, Command(filepath.Join(t.TempDir(), "nonexistent")), "")
+	Require(t, strings.HasSuffix(st.Logged(), "\npipeline failed in producer\n"))
+}
+
+// Function expectReport verifies the status of st, as st.Expect does, but first replaces
+// the value on any duration line of the log with "D", as the time a command takes varies.
+func expectReport(t *testing.T, st *StubReporter, failed, killed bool, log string) {
+	t.Helper()
+	lines := strings.SplitAfter(st.Logged(), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "duration: ") {
+			lines[i] = "duration: D\n"
+		}
+	}
+	st.log.Reset()
+	st.log.WriteString(strings.Join(lines, ""))
+	st.Expect(t, failed, killed, log)
+}
+
+func TestCmdReportDuration(t *testing.T) {
+	c := Command("/bin/sh", "-c", "sleep 0.2; exit 1")
+	var st StubReporter
+	c.Run(&st, "")
+	lines := strings.Split(st.Logged(), "\n")
+	Expect(t, "", lines[len(lines)-1])
+	text, ok := strings.CutPrefix(lines[len(lines)-2], "duration: ")
+	Require(t, ok)
+	d, e := time.ParseDuration(text)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if d < 200*time.Millisecond || d > 5*time.Second {
+		t.Error("unlikely duration", d)
+	}
+
+	st.Reset()
+	c.WantCode(1)
+	c.Run(&st, "")
+	st.Expect(t, false, false, "")
+}
+
+func TestCmdSignalName(t *testing.T) {
+	c := Command("/bin/sh", "-c", "kill -SEGV $$")
+	var st StubReporter
+	c.Run(&st, "")
+	st.Expect(t, true, true, "command terminated by signal: segmentation fault (SIGSEGV)\n")
+
+	c = Command("/bin/sh", "-c", "kill -USR1 $$")
+	st.Reset()
+	c.Run(&st, "")
+	st.Expect(t, true, true, "command terminated by signal: user defined signal 1 (SIGUSR1)\n")
+}
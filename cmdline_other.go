@@ -0,0 +1,12 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+//go:build !windows
+
+package gotest
+
+import "os/exec"
+
+// Function setCmdLine does nothing on this system, where arguments are passed
+// to commands separately rather than as a single command line.
+func setCmdLine(cmd *exec.Cmd, line string) {}
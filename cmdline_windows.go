@@ -0,0 +1,20 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+//go:build windows
+
+package gotest
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Function setCmdLine arranges for cmd to be started with exactly the command line
+// line, instead of one built by quoting its arguments.
+func setCmdLine(cmd *exec.Cmd, line string) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	cmd.SysProcAttr.CmdLine = line
+}
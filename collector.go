@@ -0,0 +1,64 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Type *Collector is a StubReporter that also collects the messages of all errors reported.
+//
+// Like StubReporter, Collector never terminates a test; FailNow, Fatal, and Fatalf only record
+// the failure. So a series of assertions may be made against a Collector, and afterward every
+// failure may be examined, rather than only the first.
+type Collector struct {
+	StubReporter
+	messages []string
+}
+
+// Messages returns the messages of the errors reported by Error, Errorf, Fatal, and Fatalf,
+// in the order they were reported.
+//
+// Each message is formatted as it would be by Log or Logf, but without a final newline.
+// The result is a copy; changing it does not affect the Collector.
+func (c *Collector) Messages() []string {
+	return slices.Clone(c.messages)
+}
+
+// Method add records an error message.
+func (c *Collector) add(msg string) {
+	c.messages = append(c.messages, strings.TrimSuffix(msg, "\n"))
+}
+
+// Error records the message and calls StubReporter.Error.
+func (c *Collector) Error(args ...any) {
+	c.add(fmt.Sprintln(args...))
+	c.StubReporter.Error(args...)
+}
+
+// Errorf records the message and calls StubReporter.Errorf.
+func (c *Collector) Errorf(format string, args ...any) {
+	c.add(fmt.Sprintf(format, args...))
+	c.StubReporter.Errorf(format, args...)
+}
+
+// Fatal records the message and calls StubReporter.Fatal.
+func (c *Collector) Fatal(args ...any) {
+	c.add(fmt.Sprintln(args...))
+	c.StubReporter.Fatal(args...)
+}
+
+// Fatalf records the message and calls StubReporter.Fatalf.
+func (c *Collector) Fatalf(format string, args ...any) {
+	c.add(fmt.Sprintf(format, args...))
+	c.StubReporter.Fatalf(format, args...)
+}
+
+// Reset returns a Collector to the initial state.
+func (c *Collector) Reset() {
+	c.StubReporter.Reset()
+	c.messages = nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"testing"
+)
+
+var _ Reporter = &Collector{}
+
+func TestCollector(t *testing.T) {
+	var c Collector
+	Expect(t, 0, len(c.Messages()))
+
+	Expect(&c, 1, 2)
+	c.Log("not an error")
+	Require(&c, false)
+	c.Errorf("%d%s", 3, "rd")
+	c.Fail()
+	c.Error("fourth", 4)
+	c.Fatalf("fifth\n")
+
+	ExpectSlice(t, []string{
+		"Expected 1 but actual value was 2",
+		"Required condition failed",
+		"3rd",
+		"fourth 4",
+		"fifth",
+	}, c.Messages())
+	c.Messages()[0] = "changed"
+	Expect(t, "Expected 1 but actual value was 2", c.Messages()[0])
+	Expect(t, true, c.Failed())
+	Expect(t, true, c.Killed())
+	Expect(t, "Expected 1 but actual value was 2\nnot an error\nRequired condition failed\n3rd\nfourth 4\nfifth\n", c.Logged())
+
+	c.Reset()
+	Expect(t, 0, len(c.Messages()))
+	c.Expect(t, false, false, "")
+}
+
+func TestCollectorMessagesOnly(t *testing.T) {
+	var c Collector
+	c.Log("a")
+	c.Fail()
+	c.FailNow()
+	Expect(t, 0, len(c.Messages()))
+	c.Expect(t, true, true, "a\n")
+}
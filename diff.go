@@ -0,0 +1,173 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constant maxDiffCells limits the size of the table used by lineDiff,
+// to bound the time and memory spent comparing large texts.
+const maxDiffCells = 1 << 22
+
+// Constant hunkContext is the number of unchanged lines shown around each change by lineDiff.
+const hunkContext = 3
+
+// Type diffLine is one line of the edit script computed by lineDiff.
+type diffLine struct {
+	op   byte // '-' for a line only in expected, '+' for a line only in actual, ' ' for both.
+	text string
+}
+
+// Function lineDiff compares two texts line by line and returns a description of the differences.
+//
+// The result is in the style of a unified diff: each change is shown with up to hunkContext
+// unchanged lines around it, and changes close together are grouped into hunks, each headed
+// by a line of the form "@@ -start,count +start,count @@" giving the lines of expected and
+// actual that it covers. Each following line begins with "-" for a line only in expected,
+// "+" for a line only in actual, or " " for a line in both. A line of the texts lacking a final
+// newline is marked as such. If the texts are too large to compare in detail, the differing
+// region is shown as entirely removed and then entirely added. The result ends with a newline,
+// unless it is empty, as it is when the texts are equal.
+func lineDiff(expected, actual string) string {
+	e := strings.SplitAfter(expected, "\n")
+	a := strings.SplitAfter(actual, "\n")
+	// SplitAfter always produces a final element, which is empty if the text ends with a newline.
+	if e[len(e)-1] == "" {
+		e = e[:len(e)-1]
+	}
+	if a[len(a)-1] == "" {
+		a = a[:len(a)-1]
+	}
+	return formatHunks(editScript(e, a))
+}
+
+// Function editScript returns the lines of e and a, marked as removed, added, or common,
+// in an order that transforms e into a.
+func editScript(e, a []string) []diffLine {
+	var script []diffLine
+
+	// Common lines at the start and end need no detailed comparison.
+	start := 0
+	for start < len(e) && start < len(a) && e[start] == a[start] {
+		start++
+	}
+	end := 0
+	for end < len(e)-start && end < len(a)-start && e[len(e)-1-end] == a[len(a)-1-end] {
+		end++
+	}
+	for _, s := range e[:start] {
+		script = append(script, diffLine{' ', s})
+	}
+	em, am := e[start:len(e)-end], a[start:len(a)-end]
+
+	if (len(em)+1)*(len(am)+1) > maxDiffCells {
+		for _, s := range em {
+			script = append(script, diffLine{'-', s})
+		}
+		for _, s := range am {
+			script = append(script, diffLine{'+', s})
+		}
+	} else {
+		// lcs[i][j] is the length of the longest common subsequence of em[i:] and am[j:].
+		lcs := make([][]int, len(em)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(am)+1)
+		}
+		for i := len(em) - 1; i >= 0; i-- {
+			for j := len(am) - 1; j >= 0; j-- {
+				if em[i] == am[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(em) || j < len(am) {
+			switch {
+			case i < len(em) && j < len(am) && em[i] == am[j]:
+				script = append(script, diffLine{' ', em[i]})
+				i++
+				j++
+			case j == len(am) || i < len(em) && lcs[i+1][j] >= lcs[i][j+1]:
+				script = append(script, diffLine{'-', em[i]})
+				i++
+			default:
+				script = append(script, diffLine{'+', am[j]})
+				j++
+			}
+		}
+	}
+
+	for _, s := range e[len(e)-end:] {
+		script = append(script, diffLine{' ', s})
+	}
+	return script
+}
+
+// Function formatHunks formats the changes in an edit script as hunks of a unified diff,
+// as described for lineDiff.
+func formatHunks(script []diffLine) string {
+	var b strings.Builder
+	// eLine and aLine are the numbers of lines of expected and actual before script[i].
+	eLine, aLine := 0, 0
+	for i := 0; i < len(script); {
+		if script[i].op == ' ' {
+			eLine++
+			aLine++
+			i++
+			continue
+		}
+
+		// A change starts at i; extend the hunk while the next change is near enough
+		// that the context around the two would meet or overlap.
+		lo := max(0, i-hunkContext)
+		hi := i
+		for j := i; j < len(script); j++ {
+			if script[j].op != ' ' {
+				hi = j + 1
+			} else if j-hi >= 2*hunkContext {
+				break
+			}
+		}
+		hi = min(len(script), hi+hunkContext)
+
+		eStart, aStart := eLine-(i-lo), aLine-(i-lo)
+		eCount, aCount := 0, 0
+		for _, l := range script[lo:hi] {
+			if l.op != '+' {
+				eCount++
+			}
+			if l.op != '-' {
+				aCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(eStart, eCount), hunkRange(aStart, aCount))
+		for _, l := range script[lo:hi] {
+			b.WriteByte(l.op)
+			if s, ok := strings.CutSuffix(l.text, "\n"); ok {
+				b.WriteString(s)
+			} else {
+				b.WriteString(l.text)
+				b.WriteString(" (no newline at end)")
+			}
+			b.WriteByte('\n')
+		}
+
+		eLine, aLine = eStart+eCount, aStart+aCount
+		i = hi
+	}
+	return b.String()
+}
+
+// Function hunkRange formats the range of lines covered by a hunk, given the number of
+// lines before the hunk and the number in it, in the form used in unified diff headers.
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLineDiff(t *testing.T) {
+	Expect(t, "", lineDiff("", ""))
+	Expect(t, "", lineDiff("a\nb\n", "a\nb\n"))
+	Expect(t, "@@ -1,1 +0,0 @@\n-a\n", lineDiff("a\n", ""))
+	Expect(t, "@@ -0,0 +1,1 @@\n+a\n", lineDiff("", "a\n"))
+	Expect(t, "@@ -1,1 +1,1 @@\n-a\n+a (no newline at end)\n", lineDiff("a\n", "a"))
+	Expect(t, "@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n", lineDiff("a\nb\nc\n", "a\nx\nc\n"))
+	Expect(t, "@@ -1,4 +1,4 @@\n a\n-b\n c\n+d\n e\n", lineDiff("a\nb\nc\ne\n", "a\nc\nd\ne\n"))
+	Expect(t, "@@ -1,4 +1,3 @@\n-x\n a\n b\n-y (no newline at end)\n+z (no newline at end)\n", lineDiff("x\na\nb\ny", "a\nb\nz"))
+}
+
+// Function numberedLines returns the lines "1" to "n", each followed by a newline.
+func numberedLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("%d\n", i+1)
+	}
+	return lines
+}
+
+func TestLineDiffHunks(t *testing.T) {
+	e := numberedLines(10000)
+	a := slices.Clone(e)
+	a[4999] = "changed\n"
+	Expect(t, "@@ -4997,7 +4997,7 @@\n 4997\n 4998\n 4999\n-5000\n+changed\n 5001\n 5002\n 5003\n",
+		lineDiff(strings.Join(e, ""), strings.Join(a, "")))
+
+	// Changes separated by at most twice the context share a hunk; others do not.
+	a = slices.Clone(e[:20])
+	a[1] = "x\n"
+	a[8] = "y\n"
+	a[16] = "z\n"
+	Expect(t, `@@ -1,12 +1,12 @@
+ 1
+-2
++x
+ 3
+ 4
+ 5
+ 6
+ 7
+ 8
+-9
++y
+ 10
+ 11
+ 12
+@@ -14,7 +14,7 @@
+ 14
+ 15
+ 16
+-17
++z
+ 18
+ 19
+ 20
+`, lineDiff(strings.Join(e[:20], ""), strings.Join(a, "")))
+
+	// Line numbers after an insertion account for the added lines.
+	a = append(slices.Clone(e[:2]), "new\n")
+	a = append(a, e[2:20]...)
+	a[15] = "w\n"
+	Expect(t, `@@ -1,5 +1,6 @@
+ 1
+ 2
++new
+ 3
+ 4
+ 5
+@@ -12,7 +13,7 @@
+ 12
+ 13
+ 14
+-15
++w
+ 16
+ 17
+ 18
+`, lineDiff(strings.Join(e[:20], ""), strings.Join(a, "")))
+}
+
+func TestLineDiffLarge(t *testing.T) {
+	var e, a strings.Builder
+	for i := 0; i < 3000; i++ {
+		e.WriteString("e\n")
+		a.WriteString("a\n")
+	}
+	d := lineDiff("same\n"+e.String()+"end\n", "same\n"+a.String()+"end\n")
+	Expect(t, "@@ -1,3002 +1,3002 @@\n same\n"+strings.Repeat("-e\n", 3000)+strings.Repeat("+a\n", 3000)+" end\n", d)
+}
@@ -0,0 +1,187 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Type *ErrorReporter is an implementation of the Reporter interface
+// that turns reported failures into errors.
+//
+// It allows the functions in this package, such as Expect and Require,
+// to be used outside of tests, for example in code that validates data
+// and returns an error describing any problems.
+//
+// The Error and Errorf methods record an error. FailNow, and so also Fatal and Fatalf,
+// stop the function being run by calling panic with a private value; Run recovers
+// from that panic and returns the recorded errors. FailNow must therefore only be
+// called from the goroutine executing Run, and only while Run is executing;
+// otherwise the panic will not be recovered. SkipNow, Skip, and Skipf stop the
+// function in the same way, subject to the same restrictions, but without
+// marking the ErrorReporter as failed.
+//
+// Log and Logf discard their arguments. Functions registered by Cleanup
+// are called, most recently registered first, when the function passed to Run
+// finishes or is stopped.
+//
+// The zero value is ready to use.
+type ErrorReporter struct {
+	errs     []error
+	failed   bool
+	cleanups []func()
+	name     string
+}
+
+// Type errorReporterStop is the value passed to panic by ErrorReporter.FailNow.
+type errorReporterStop struct {
+	er *ErrorReporter
+}
+
+// Run resets the ErrorReporter, calls f, and returns any errors reported while f ran.
+//
+// Multiple errors are combined with errors.Join. If the ErrorReporter was marked failed
+// without any error being reported, Run returns a generic error. If f panics for any
+// reason other than a call to FailNow on this ErrorReporter, the panic continues.
+func (er *ErrorReporter) Run(f func()) (err error) {
+	er.errs = nil
+	er.failed = false
+	er.cleanups = nil
+	defer func() {
+		r := recover()
+		er.runCleanup()
+		if r != nil {
+			if stop, ok := r.(errorReporterStop); !ok || stop.er != er {
+				panic(r)
+			}
+		}
+		err = er.Err()
+	}()
+	f()
+	return
+}
+
+// Err returns the errors reported so far, combined with errors.Join,
+// or nil if the ErrorReporter has not been marked failed.
+func (er *ErrorReporter) Err() error {
+	if !er.failed {
+		return nil
+	}
+	if len(er.errs) == 0 {
+		return errors.New("failed")
+	}
+	return errors.Join(er.errs...)
+}
+
+// Cleanup registers a function to be called when the function being run by Run finishes.
+func (er *ErrorReporter) Cleanup(f func()) {
+	er.cleanups = append(er.cleanups, f)
+}
+
+// TempDir creates a new temporary directory and returns its name.
+//
+// The directory and its contents are removed when the function being run by Run finishes.
+// If the directory cannot be created, TempDir calls Fatal.
+func (er *ErrorReporter) TempDir() string {
+	dir, e := os.MkdirTemp("", "ErrorReporter")
+	if e != nil {
+		er.Fatal(e)
+		return ""
+	}
+	er.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// Name returns the name set by SetName, or "" if SetName has not been called.
+func (er *ErrorReporter) Name() string {
+	return er.name
+}
+
+// SetName sets the name returned by Name.
+func (er *ErrorReporter) SetName(name string) {
+	er.name = name
+}
+
+// Method runCleanup calls the functions registered by Cleanup,
+// most recently registered first, and forgets them.
+func (er *ErrorReporter) runCleanup() {
+	for len(er.cleanups) > 0 {
+		last := len(er.cleanups) - 1
+		f := er.cleanups[last]
+		er.cleanups = er.cleanups[:last]
+		f()
+	}
+}
+
+// Helper marks a function as a helper function.
+//
+// The ErrorReporter version of Helper does nothing.
+func (er *ErrorReporter) Helper() {}
+
+// Fail marks the ErrorReporter as failed, without recording a specific error.
+func (er *ErrorReporter) Fail() {
+	er.failed = true
+}
+
+// Failed returns whether the ErrorReporter was marked failed.
+func (er *ErrorReporter) Failed() bool {
+	return er.failed
+}
+
+// FailNow marks the ErrorReporter as failed and stops the function being run by Run.
+func (er *ErrorReporter) FailNow() {
+	er.Fail()
+	panic(errorReporterStop{er})
+}
+
+// Log does nothing.
+func (er *ErrorReporter) Log(args ...any) {}
+
+// Logf does nothing.
+func (er *ErrorReporter) Logf(format string, args ...any) {}
+
+// Error records an error with text formatted as if by fmt.Println, without the final newline.
+func (er *ErrorReporter) Error(args ...any) {
+	er.Fail()
+	er.errs = append(er.errs, errors.New(strings.TrimSuffix(fmt.Sprintln(args...), "\n")))
+}
+
+// Errorf records an error created by fmt.Errorf, so the %w verb may be used.
+func (er *ErrorReporter) Errorf(format string, args ...any) {
+	er.Fail()
+	er.errs = append(er.errs, fmt.Errorf(format, args...))
+}
+
+// Fatal calls Error and FailNow.
+func (er *ErrorReporter) Fatal(args ...any) {
+	er.Error(args...)
+	er.FailNow()
+}
+
+// Fatalf calls Errorf and FailNow.
+func (er *ErrorReporter) Fatalf(format string, args ...any) {
+	er.Errorf(format, args...)
+	er.FailNow()
+}
+
+// SkipNow stops the function being run by Run, without marking the ErrorReporter as failed.
+// Run then returns any errors reported before the call.
+func (er *ErrorReporter) SkipNow() {
+	panic(errorReporterStop{er})
+}
+
+// Skip calls Log and SkipNow.
+func (er *ErrorReporter) Skip(args ...any) {
+	er.Log(args...)
+	er.SkipNow()
+}
+
+// Skipf calls Logf and SkipNow.
+func (er *ErrorReporter) Skipf(format string, args ...any) {
+	er.Logf(format, args...)
+	er.SkipNow()
+}
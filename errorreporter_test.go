@@ -0,0 +1,168 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+var _ Reporter = &ErrorReporter{}
+
+func TestErrorReporterRun(t *testing.T) {
+	var er ErrorReporter
+	e := er.Run(func() {
+		Expect(&er, 1, 1)
+		er.Log("ignored")
+	})
+	Require(t, e == nil)
+	Expect(t, false, er.Failed())
+
+	reached := false
+	e = er.Run(func() {
+		er.Error("first")
+		Expect(&er, 1, 2)
+		reached = true
+	})
+	Expect(t, false, reached)
+	Expect(t, true, er.Failed())
+	ExpectErrorMessage(t, "first\nExpected 1 but actual value was 2", e)
+
+	e = er.Run(func() {
+		Expect(NotFatal{&er}, "a", "b")
+		er.Errorf("wrapped: %w", io.EOF)
+		reached = true
+	})
+	Expect(t, true, reached)
+	ExpectErrorMessage(t, "Expected a but actual value was b\nwrapped: EOF", e)
+	Require(t, errors.Is(e, io.EOF))
+
+	e = er.Run(func() {
+		er.Fail()
+	})
+	ExpectErrorMessage(t, "failed", e)
+	ExpectErrorMessage(t, "failed", er.Err())
+
+	e = er.Run(func() {})
+	Require(t, e == nil)
+	Require(t, er.Err() == nil)
+}
+
+func TestErrorReporterSkip(t *testing.T) {
+	var er ErrorReporter
+	reached := false
+	e := er.Run(func() {
+		er.Skip("not applicable")
+		reached = true
+	})
+	Expect(t, false, reached)
+	Require(t, e == nil)
+
+	e = er.Run(func() {
+		er.Error("first")
+		er.Skipf("%s", "skipping")
+		reached = true
+	})
+	Expect(t, false, reached)
+	ExpectErrorMessage(t, "first", e)
+
+	e = er.Run(func() {
+		er.SkipNow()
+	})
+	Require(t, e == nil)
+}
+
+func TestErrorReporterCleanup(t *testing.T) {
+	var er ErrorReporter
+	var order []int
+	e := er.Run(func() {
+		er.Cleanup(func() { order = append(order, 1) })
+		er.Cleanup(func() { order = append(order, 2) })
+		er.Fatal("stop")
+		order = append(order, 0)
+	})
+	ExpectErrorMessage(t, "stop", e)
+	ExpectSlice(t, []int{2, 1}, order)
+
+	order = nil
+	e = er.Run(func() {
+		er.Cleanup(func() { er.Error("from cleanup") })
+	})
+	ExpectErrorMessage(t, "from cleanup", e)
+
+	MustPanic(t, func() {
+		er.Run(func() {
+			er.Cleanup(func() { order = append(order, 3) })
+			panic("other")
+		})
+	})
+	ExpectSlice(t, []int{3}, order)
+}
+
+func TestErrorReporterTempDir(t *testing.T) {
+	var er ErrorReporter
+	var dir string
+	e := er.Run(func() {
+		dir = er.TempDir()
+		info, e := os.Stat(dir)
+		Require(t, e == nil)
+		Require(t, info.IsDir())
+	})
+	Require(t, e == nil)
+	_, e = os.Stat(dir)
+	Require(t, errors.Is(e, fs.ErrNotExist))
+}
+
+func TestErrorReporterName(t *testing.T) {
+	var er ErrorReporter
+	Expect(t, "", er.Name())
+	er.SetName("config check")
+	e := er.Run(func() {
+		Expect(t, "config check", er.Name())
+	})
+	Require(t, e == nil)
+}
+
+func TestErrorReporterFatalf(t *testing.T) {
+	var er ErrorReporter
+	e := er.Run(func() {
+		er.Fatalf("%d problems", 99)
+	})
+	ExpectErrorMessage(t, "99 problems", e)
+}
+
+func TestErrorReporterPanics(t *testing.T) {
+	var er ErrorReporter
+	x := MustPanic(t, func() {
+		er.Run(func() {
+			panic("other")
+		})
+	})
+	Expect(t, "other", x.(string))
+
+	x = MustPanic(t, func() {
+		er.Run(func() {
+			er.Error("failed first")
+			panic("other")
+		})
+	})
+	Expect(t, "other", x.(string))
+
+	var outer ErrorReporter
+	e := outer.Run(func() {
+		er.Run(func() {
+			outer.FailNow()
+		})
+	})
+	ExpectErrorMessage(t, "failed", e)
+	Require(t, er.Err() == nil)
+
+	x = MustPanic(t, func() {
+		er.FailNow()
+	})
+	Require(t, x == errorReporterStop{&er})
+}
@@ -0,0 +1,173 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ExpectError verifies that err is not nil.
+func ExpectError(t Reporter, err error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Expected an error but error was nil")
+	}
+}
+
+// ExpectErrorIs verifies that err is not nil and that errors.Is(err, target) is true.
+// It is the same as ExpectContainsError, under a name that matches errors.Is.
+func ExpectErrorIs(t Reporter, err, target error) {
+	t.Helper()
+	ExpectContainsError(t, err, target)
+}
+
+// ExpectErrorContains verifies that err is not nil and that err.Error() contains substr.
+func ExpectErrorContains(t Reporter, err error, substr string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("Expected error containing %q but error was nil", substr)
+	} else if actual := err.Error(); !strings.Contains(actual, substr) {
+		t.Fatalf("Expected error containing %q but actual message was %q", substr, actual)
+	}
+}
+
+// ExpectErrorMessage verifies that err is not nil and that err.Error() is exactly want.
+//
+// This is intended for testing code that formats error messages, where the text
+// of the message is the thing being tested. To test the identity of an error,
+// use errors.Is instead.
+func ExpectErrorMessage(t Reporter, want string, err error) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("Expected error with message %q but error was nil", want)
+	} else if actual := err.Error(); actual != want {
+		t.Fatalf("Expected error message %q but actual message was %q", want, actual)
+	}
+}
+
+// ExpectContainsError verifies that err is not nil and that errors.Is(err, target) is true.
+//
+// On failure, every error in the tree of wrapped errors rooted at err is reported,
+// including those combined by errors.Join, to help show why target was not found.
+func ExpectContainsError(t Reporter, err, target error) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("Expected error matching", target, "but error was nil")
+	} else if !errors.Is(err, target) {
+		t.Error("Expected error matching", target, "but it was not found in:")
+		reportErrors(t, err)
+		t.FailNow()
+	}
+}
+
+// ExpectErrorChainLength verifies that the tree of errors rooted at err contains exactly n errors.
+//
+// The count includes err itself and every error it wraps, directly or indirectly,
+// through either an Unwrap() error method or an Unwrap() []error method.
+// So a nil error has length 0, an error that wraps nothing has length 1,
+// and fmt.Errorf("context: %w", e) has length one more than e.
+// On mismatch, every error in the tree is reported.
+func ExpectErrorChainLength(t Reporter, err error, n int) {
+	t.Helper()
+	length := 0
+	walkErrors(err, 0, func(error, int) {
+		length++
+	})
+	if length != n {
+		t.Errorf("Expected error chain length %d but actual length was %d", n, length)
+		reportErrors(t, err)
+		t.FailNow()
+	}
+}
+
+// Function reportErrors reports, as errors through t, each error in the tree rooted at err,
+// indented according to its depth.
+func reportErrors(t Reporter, err error) {
+	t.Helper()
+	walkErrors(err, 0, func(e error, depth int) {
+		t.Errorf("%s%T: %q", strings.Repeat("  ", depth+1), e, e.Error())
+	})
+}
+
+// ExpectAllNil verifies that every one of errs is nil.
+//
+// Every non-nil error is reported, with its position in errs (counting from 0),
+// before the test is terminated. This suits a series of independent setup steps,
+// any of which might fail.
+func ExpectAllNil(t Reporter, errs ...error) {
+	t.Helper()
+	ok := true
+	for i, e := range errs {
+		if e != nil {
+			t.Errorf("error %d: %v", i, e)
+			ok = false
+		}
+	}
+	if !ok {
+		t.FailNow()
+	}
+}
+
+// ExpectCloses calls c.Close and verifies that it returns nil.
+// A non-nil error is reported as a fatal error.
+func ExpectCloses(t Reporter, c io.Closer) {
+	t.Helper()
+	if e := c.Close(); e != nil {
+		t.Fatal("Close failed:", e)
+	}
+}
+
+// ExpectCloseError calls c.Close and verifies that it returns an error,
+// as when closing a resource whose final flush fails. The error is returned
+// for further checks; a nil error is reported as a fatal error.
+func ExpectCloseError(t Reporter, c io.Closer) error {
+	t.Helper()
+	e := c.Close()
+	if e == nil {
+		t.Fatal("Expected Close to fail but it returned nil")
+	}
+	return e
+}
+
+// ExpectEventuallyNoError calls f repeatedly, interval apart, until it returns nil,
+// and reports a fatal error if it has not done so within timeout.
+//
+// This suits operations that succeed only once some resource is ready, such as
+// connecting to a server that is starting up. f is always called at least once.
+// On failure, the number of calls and the last error returned are reported.
+func ExpectEventuallyNoError(t Reporter, timeout, interval time.Duration, f func() error) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for calls := 1; ; calls++ {
+		e := f()
+		if e == nil {
+			return
+		}
+		if time.Now().Add(interval).After(deadline) {
+			t.Fatalf("Still failing after %v (%d calls); last error: %v", timeout, calls, e)
+			return // In case t.Fatal has been overridden to not terminate the test case.
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Function walkErrors calls f for err and for each error wrapped by err, directly or indirectly,
+// in depth-first order. The depth is 0 for err itself, 1 for errors it wraps directly, and so on.
+func walkErrors(err error, depth int, f func(e error, depth int)) {
+	if err == nil {
+		return
+	}
+	f(err, depth)
+	switch u := err.(type) {
+	case interface{ Unwrap() error }:
+		walkErrors(u.Unwrap(), depth+1, f)
+	case interface{ Unwrap() []error }:
+		for _, e := range u.Unwrap() {
+			walkErrors(e, depth+1, f)
+		}
+	}
+}
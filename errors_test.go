@@ -0,0 +1,176 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestExpectError(t *testing.T) {
+	var st StubReporter
+	ExpectError(&st, io.EOF)
+	st.Expect(t, false, false, "")
+
+	ExpectError(&st, nil)
+	st.Expect(t, true, true, "Expected an error but error was nil\n")
+}
+
+func TestExpectErrorIs(t *testing.T) {
+	var st StubReporter
+	ExpectErrorIs(&st, fmt.Errorf("reading: %w", io.EOF), io.EOF)
+	st.Expect(t, false, false, "")
+
+	ExpectErrorIs(&st, nil, io.EOF)
+	st.Expect(t, true, true, "Expected error matching EOF but error was nil\n")
+}
+
+func TestExpectErrorContains(t *testing.T) {
+	var st StubReporter
+	ExpectErrorContains(&st, errors.New("open x: permission denied"), "permission")
+	st.Expect(t, false, false, "")
+
+	ExpectErrorContains(&st, nil, "permission")
+	st.Expect(t, true, true, "Expected error containing \"permission\" but error was nil\n")
+
+	st.Reset()
+	ExpectErrorContains(&st, io.EOF, "permission")
+	st.Expect(t, true, true, "Expected error containing \"permission\" but actual message was \"EOF\"\n")
+}
+
+func TestExpectErrorMessage(t *testing.T) {
+	var st StubReporter
+	ExpectErrorMessage(&st, "bad thing", errors.New("bad thing"))
+	st.Expect(t, false, false, "")
+
+	ExpectErrorMessage(&st, "bad thing", nil)
+	st.Expect(t, true, true, "Expected error with message \"bad thing\" but error was nil\n")
+
+	st.Reset()
+	ExpectErrorMessage(&st, "bad thing", errors.New("worse thing"))
+	st.Expect(t, true, true, "Expected error message \"bad thing\" but actual message was \"worse thing\"\n")
+}
+
+func TestExpectContainsError(t *testing.T) {
+	joined := errors.Join(fmt.Errorf("reading: %w", io.EOF), errors.New("closing"))
+
+	var st StubReporter
+	ExpectContainsError(&st, joined, io.EOF)
+	st.Expect(t, false, false, "")
+
+	ExpectContainsError(&st, nil, io.EOF)
+	st.Expect(t, true, true, "Expected error matching EOF but error was nil\n")
+
+	st.Reset()
+	ExpectContainsError(&st, fmt.Errorf("wrapped: %w", joined), fs.ErrNotExist)
+	st.Expect(t, true, true, `Expected error matching file does not exist but it was not found in:
+  *fmt.wrapError: "wrapped: reading: EOF\nclosing"
+    *errors.joinError: "reading: EOF\nclosing"
+      *fmt.wrapError: "reading: EOF"
+        *errors.errorString: "EOF"
+      *errors.errorString: "closing"
+`)
+}
+
+func TestExpectErrorChainLength(t *testing.T) {
+	var st StubReporter
+	ExpectErrorChainLength(&st, nil, 0)
+	st.Expect(t, false, false, "")
+	ExpectErrorChainLength(&st, io.EOF, 1)
+	st.Expect(t, false, false, "")
+	ExpectErrorChainLength(&st, fmt.Errorf("a: %w", fmt.Errorf("b: %w", io.EOF)), 3)
+	st.Expect(t, false, false, "")
+	ExpectErrorChainLength(&st, errors.Join(fmt.Errorf("a: %w", io.EOF), io.ErrUnexpectedEOF), 4)
+	st.Expect(t, false, false, "")
+
+	ExpectErrorChainLength(&st, fmt.Errorf("a: %w", io.EOF), 3)
+	st.Expect(t, true, true, `Expected error chain length 3 but actual length was 2
+  *fmt.wrapError: "a: EOF"
+    *errors.errorString: "EOF"
+`)
+
+	st.Reset()
+	ExpectErrorChainLength(&st, nil, 1)
+	st.Expect(t, true, true, "Expected error chain length 1 but actual length was 0\n")
+}
+
+func TestExpectAllNil(t *testing.T) {
+	var st StubReporter
+	ExpectAllNil(&st)
+	st.Expect(t, false, false, "")
+	ExpectAllNil(&st, nil, nil)
+	st.Expect(t, false, false, "")
+
+	ExpectAllNil(&st, nil, io.EOF, nil, errors.New("second"))
+	st.Expect(t, true, true, "error 1: EOF\nerror 3: second\n")
+}
+
+// Type closer is an io.Closer that returns a fixed error.
+type closer struct {
+	err    error
+	closed bool
+}
+
+func (c *closer) Close() error {
+	c.closed = true
+	return c.err
+}
+
+func TestExpectCloses(t *testing.T) {
+	var st StubReporter
+	c := &closer{}
+	ExpectCloses(&st, c)
+	st.Expect(t, false, false, "")
+	Expect(t, true, c.closed)
+
+	ExpectCloses(&st, &closer{err: errors.New("disk full")})
+	st.Expect(t, true, true, "Close failed: disk full\n")
+}
+
+func TestExpectCloseError(t *testing.T) {
+	var st StubReporter
+	full := errors.New("disk full")
+	e := ExpectCloseError(&st, &closer{err: full})
+	st.Expect(t, false, false, "")
+	Expect(t, full, e)
+
+	c := &closer{}
+	e = ExpectCloseError(&st, c)
+	st.Expect(t, true, true, "Expected Close to fail but it returned nil\n")
+	Expect(t, nil, e)
+	Expect(t, true, c.closed)
+}
+
+func TestExpectEventuallyNoError(t *testing.T) {
+	var st StubReporter
+	calls := 0
+	ExpectEventuallyNoError(&st, time.Second, time.Millisecond, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("not ready")
+		}
+		return nil
+	})
+	st.Expect(t, false, false, "")
+	Expect(t, 3, calls)
+
+	calls = 0
+	ExpectEventuallyNoError(&st, 0, time.Millisecond, func() error {
+		calls++
+		return nil
+	})
+	st.Expect(t, false, false, "")
+	Expect(t, 1, calls)
+
+	calls = 0
+	ExpectEventuallyNoError(&st, 30*time.Millisecond, 20*time.Millisecond, func() error {
+		calls++
+		return fmt.Errorf("attempt %d refused", calls)
+	})
+	st.Expect(t, true, true, "Still failing after 30ms (2 calls); last error: attempt 2 refused\n")
+}
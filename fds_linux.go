@@ -0,0 +1,36 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Function openFds returns the file descriptors open in the current process,
+// mapped to their targets, as listed in /proc/self/fd.
+//
+// The descriptor used to read the directory itself is omitted.
+func openFds() (map[string]string, error) {
+	dir, e := filepath.EvalSymlinks("/proc/self/fd")
+	if e != nil {
+		return nil, e
+	}
+	entries, e := os.ReadDir(dir)
+	if e != nil {
+		return nil, e
+	}
+	fds := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		target, e := os.Readlink(filepath.Join(dir, entry.Name()))
+		if e != nil {
+			// Most likely the descriptor was closed while we were reading.
+			continue
+		}
+		if target != dir {
+			fds[entry.Name()] = target
+		}
+	}
+	return fds, nil
+}
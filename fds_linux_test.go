@@ -0,0 +1,38 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenFds(t *testing.T) {
+	before, e := openFds()
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	path := filepath.Join(t.TempDir(), "file")
+	f, e := os.Create(path)
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer f.Close()
+
+	after, e := openFds()
+	if e != nil {
+		t.Fatal(e)
+	}
+	Expect(t, len(before)+1, len(after))
+	var found bool
+	for fd, target := range after {
+		if before[fd] != target {
+			Expect(t, path, target)
+			found = true
+		}
+	}
+	Require(t, found)
+}
@@ -0,0 +1,11 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+//go:build !linux
+
+package gotest
+
+// Function openFds is not supported on this system; it always returns nil, nil.
+func openFds() (map[string]string, error) {
+	return nil, nil
+}
@@ -13,16 +13,29 @@
 // be changed with the NotFatal wrapper.
 package gotest
 
+import (
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
 // Type Reporter is an interface satisfied by the testing.T, .B, and .F types.
 //
 // Reporter includes the methods involved in reporting the status of test cases.
 // It also includes Helper, so that the helper functions defined in this package
-// can properly mark themselves as helper functions.
+// can properly mark themselves as helper functions, and a few other methods,
+// such as Cleanup, Name, and TempDir, that helper functions may need.
 //
 // In future, more methods from the intersection of T, B, and F may be added.
 // Be warned that this may break code containing types designed to implement Reporter;
 // you create such types at your own risk.
 type Reporter interface {
+	Cleanup(f func())
 	Error(args ...any)
 	Errorf(format string, args ...any)
 	Fail()
@@ -33,6 +46,11 @@ type Reporter interface {
 	Helper()
 	Log(args ...any)
 	Logf(format string, args ...any)
+	Name() string
+	Skip(args ...any)
+	SkipNow()
+	Skipf(format string, args ...any)
+	TempDir() string
 }
 
 // Require fails and terminates the running test if the condition is false.
@@ -51,6 +69,318 @@ func Expect[T comparable](t Reporter, expected, actual T) {
 	}
 }
 
+// Expectf is like Expect, but on failure the message begins with the result of
+// formatting args according to format, as with fmt.Sprintf. This identifies,
+// for example, which row of a table test failed.
+func Expectf[T comparable](t Reporter, expected, actual T, format string, args ...any) {
+	t.Helper()
+	if actual != expected {
+		t.Fatalf("%s: Expected %v but actual value was %v", fmt.Sprintf(format, args...), expected, actual)
+	}
+}
+
+// ExpectNotEqual verifies that a and b differ, as when a regenerated token should change.
+func ExpectNotEqual[T comparable](t Reporter, a, b T) {
+	t.Helper()
+	if a == b {
+		t.Fatal("Expected values to differ, but both were", a)
+	}
+}
+
+// ExpectClose verifies that actual differs from expected by at most tolerance.
+//
+// On failure, both values and their difference are reported. A NaN is never
+// close to any value, even another NaN. An infinity is close only to an equal infinity.
+func ExpectClose(t Reporter, expected, actual, tolerance float64) {
+	t.Helper()
+	if math.IsNaN(expected) || math.IsNaN(actual) {
+		t.Fatalf("Expected %v but actual value was %v; NaN is not close to any value", expected, actual)
+	} else if expected != actual {
+		diff := math.Abs(actual - expected)
+		if math.IsInf(expected, 0) || math.IsInf(actual, 0) || !(diff <= tolerance) {
+			t.Fatalf("Expected %v within %v but actual value was %v (difference %v)", expected, tolerance, actual, diff)
+		}
+	}
+}
+
+// ExpectCloseRel verifies that actual differs from expected by at most tolerance,
+// relative to the larger of their magnitudes. For example, with tolerance 1e-9,
+// the values must agree to about 9 significant digits. This suits values of large
+// or varying magnitude, for which a fixed tolerance is unsuitable.
+//
+// NaNs and infinities are treated as by ExpectClose.
+func ExpectCloseRel(t Reporter, expected, actual, tolerance float64) {
+	t.Helper()
+	if math.IsNaN(expected) || math.IsNaN(actual) {
+		t.Fatalf("Expected %v but actual value was %v; NaN is not close to any value", expected, actual)
+	} else if expected != actual {
+		rel := math.Abs(actual-expected) / math.Max(math.Abs(expected), math.Abs(actual))
+		if math.IsInf(expected, 0) || math.IsInf(actual, 0) || !(rel <= tolerance) {
+			t.Fatalf("Expected %v within relative tolerance %v but actual value was %v (relative difference %v)",
+				expected, tolerance, actual, rel)
+		}
+	}
+}
+
+// ExpectEqual verifies that actual equals expected, whatever their types.
+//
+// If the values have the same type and are comparable, they are compared with ==;
+// note that this compares pointers by address. Otherwise they are compared with
+// reflect.DeepEqual, so slices and maps, for example, are compared element by element.
+// Values of different types are never equal.
+//
+// On failure, both values are reported in Go syntax, on separate lines, followed by
+// the first differing index of slices and arrays, or the first differing key of maps,
+// or for other values, the differences between them line by line; structs are shown
+// with one field per line.
+// Prefer Expect when the types are known to be comparable, as it also checks
+// at compile time that the two values have the same type.
+func ExpectEqual(t Reporter, expected, actual any) {
+	t.Helper()
+	if !equal(expected, actual) {
+		t.Errorf("Expected: %#v", expected)
+		t.Errorf("Actual:   %#v", actual)
+		if ev, av := reflect.ValueOf(expected), reflect.ValueOf(actual); ev.IsValid() && av.IsValid() {
+			if ev.Type() != av.Type() {
+				t.Errorf("types differ: %T, %T", expected, actual)
+			} else {
+				reportDifference(t, ev, av)
+			}
+		}
+		t.FailNow()
+	}
+}
+
+// ExpectDeep verifies that actual is deeply equal to expected, as determined by reflect.DeepEqual.
+//
+// This suits values that are not comparable, such as slices, maps, and structs
+// containing them. Unlike ExpectEqual, it also compares pointers by what they point to.
+// On failure, both values are reported in Go syntax, on separate lines.
+// ExpectDeep is slower than Expect, and as it accepts any types, mistakes such as comparing
+// an int to an int64 are caught only when the test runs; so use it only when necessary.
+func ExpectDeep(t Reporter, expected, actual any) {
+	t.Helper()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected: %#v", expected)
+		t.Errorf("Actual:   %#v", actual)
+		t.FailNow()
+	}
+}
+
+// ExpectEqualNormalizingSpace verifies that expected and actual are equal after
+// normalizing whitespace in each: runs of white space are collapsed to a single space,
+// and leading and trailing white space is removed.
+//
+// On failure, the normalized texts are reported, followed by a word by word diff.
+func ExpectEqualNormalizingSpace(t Reporter, expected, actual string) {
+	t.Helper()
+	ew, aw := strings.Fields(expected), strings.Fields(actual)
+	if slices.Equal(ew, aw) {
+		return
+	}
+	t.Errorf("Expected %q but actual value was %q (normalizing white space)", strings.Join(ew, " "), strings.Join(aw, " "))
+	t.Errorf("differing words:\n%s", lineDiff(wordLines(ew), wordLines(aw)))
+	t.FailNow()
+}
+
+// Function wordLines returns the words, each followed by a newline.
+func wordLines(words []string) string {
+	var b strings.Builder
+	for _, w := range words {
+		b.WriteString(w)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Function reportDifference reports how two unequal values of the same type differ,
+// as described for ExpectEqual.
+func reportDifference(t Reporter, expected, actual reflect.Value) {
+	t.Helper()
+	switch expected.Kind() {
+	case reflect.Slice, reflect.Array:
+		n := min(expected.Len(), actual.Len())
+		for i := 0; i < n; i++ {
+			if e, a := expected.Index(i), actual.Index(i); !reflect.DeepEqual(e.Interface(), a.Interface()) {
+				t.Errorf("first difference at index %d: expected %#v but actual value was %#v", i, e, a)
+				return
+			}
+		}
+		t.Errorf("lengths differ: expected %d but actual length was %d", expected.Len(), actual.Len())
+
+	case reflect.Map:
+		keys := append(expected.MapKeys(), actual.MapKeys()...)
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprintf("%#v", keys[i]) < fmt.Sprintf("%#v", keys[j])
+		})
+		for _, k := range keys {
+			e, a := expected.MapIndex(k), actual.MapIndex(k)
+			switch {
+			case !a.IsValid():
+				t.Errorf("first difference at key %#v: missing", k)
+			case !e.IsValid():
+				t.Errorf("first difference at key %#v: unexpected", k)
+			case !reflect.DeepEqual(e.Interface(), a.Interface()):
+				t.Errorf("first difference at key %#v: expected %#v but actual value was %#v", k, e, a)
+			default:
+				continue
+			}
+			return
+		}
+
+	default:
+		if d := lineDiff(diffText(expected), diffText(actual)); d != "" {
+			t.Errorf("differences:\n%s", d)
+		}
+	}
+}
+
+// Function diffText formats a value for comparison line by line: a string as itself,
+// a struct with one field per line, and anything else in Go syntax.
+func diffText(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Struct:
+		var b strings.Builder
+		for i := 0; i < v.NumField(); i++ {
+			fmt.Fprintf(&b, "%s: %#v\n", v.Type().Field(i).Name, v.Field(i))
+		}
+		return b.String()
+	default:
+		return fmt.Sprintf("%#v\n", v)
+	}
+}
+
+// Function equal reports whether a and b are equal, as described for ExpectEqual.
+func equal(a, b any) bool {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	if av.IsValid() && bv.IsValid() && av.Type() == bv.Type() && av.Comparable() && bv.Comparable() {
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// ExpectStable calls f n times and verifies that it returns the same value each time.
+//
+// On the first call whose result differs from that of the first call,
+// ExpectStable reports the call number and both values.
+func ExpectStable[T comparable](t Reporter, n int, f func() T) {
+	t.Helper()
+	if n <= 0 {
+		return
+	}
+	first := f()
+	for i := 2; i <= n; i++ {
+		if actual := f(); actual != first {
+			t.Fatal("Call", i, "returned", actual, "but first call returned", first)
+			return // In case t.Fatal has been overridden to not terminate the test case.
+		}
+	}
+}
+
+// ExpectRoundTrip verifies that unmarshal(marshal(v)) is deeply equal to v.
+//
+// Equality is tested with reflect.DeepEqual. On failure, the intermediate bytes
+// are reported along with both values. An error from marshal or unmarshal is
+// reported as a fatal error.
+func ExpectRoundTrip[T any](t Reporter, v T, marshal func(T) ([]byte, error), unmarshal func([]byte) (T, error)) {
+	t.Helper()
+	data, e := marshal(v)
+	if e != nil {
+		t.Fatalf("Marshaling %#v failed: %v", v, e)
+		return // In case t.Fatalf has been overridden to not terminate the test case.
+	}
+	back, e := unmarshal(data)
+	if e != nil {
+		t.Fatalf("Unmarshaling %q failed: %v", data, e)
+		return
+	}
+	if !reflect.DeepEqual(v, back) {
+		t.Errorf("Round trip produced %#v; expected %#v", back, v)
+		t.Errorf("intermediate bytes: %q", data)
+		t.FailNow()
+	}
+}
+
+// ExpectImplements verifies that the dynamic type of v implements the interface I.
+//
+// ExpectImplements panics if I is not an interface type.
+func ExpectImplements[I any](t Reporter, v any) {
+	t.Helper()
+	typ := reflect.TypeOf((*I)(nil)).Elem()
+	if typ.Kind() != reflect.Interface {
+		panic("gotest.ExpectImplements: " + typ.String() + " is not an interface type")
+	}
+	if _, ok := v.(I); !ok {
+		t.Fatalf("%T does not implement %v", v, typ)
+	}
+}
+
+// Type integer is a constraint satisfied by all integer types.
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// ExpectFitsIn verifies that v can be represented in the integer type T,
+// and returns v converted to T.
+//
+// On failure, v and the range of T are reported. If the test is not terminated,
+// the result is the value of the conversion T(v), which will be incorrect.
+func ExpectFitsIn[T integer](t Reporter, v int64) T {
+	t.Helper()
+	x := T(v)
+	if int64(x) != v || (x < 0) != (v < 0) {
+		bits := unsafe.Sizeof(x) * 8
+		if ^T(0) < 0 {
+			min := int64(-1) << (bits - 1)
+			t.Fatalf("%d does not fit in %T (range %d to %d)", v, x, min, -(min + 1))
+		} else {
+			t.Fatalf("%d does not fit in %T (range 0 to %d)", v, x, ^uint64(0)>>(64-bits))
+		}
+	}
+	return x
+}
+
+// ExpectSize verifies that the file at path has size want, in bytes.
+//
+// If the file can not be examined, ExpectSize reports the error from os.Stat.
+func ExpectSize(t Reporter, want int64, path string) {
+	t.Helper()
+	info, e := os.Stat(path)
+	if e != nil {
+		t.Fatal(e)
+		return // In case t.Fatal has been overridden to not terminate the test case.
+	}
+	if actual := info.Size(); actual != want {
+		t.Fatalf("Expected size %d but actual size of %s was %d", want, path, actual)
+	}
+}
+
+// ExpectLenBytes verifies that s has length want, in bytes.
+func ExpectLenBytes(t Reporter, want int, s string) {
+	t.Helper()
+	if actual := len(s); actual != want {
+		t.Fatalf("Expected length %d bytes but actual length was %d", want, actual)
+	}
+}
+
+// ExpectChanLen verifies that the channel ch holds exactly n buffered values.
+func ExpectChanLen[T any](t Reporter, ch chan T, n int) {
+	t.Helper()
+	if actual := len(ch); actual != n {
+		t.Fatalf("Expected channel length %d but actual length was %d", n, actual)
+	}
+}
+
+// ExpectChanCap verifies that the channel ch has a buffer capacity of exactly n.
+func ExpectChanCap[T any](t Reporter, ch chan T, n int) {
+	t.Helper()
+	if actual := cap(ch); actual != n {
+		t.Fatalf("Expected channel capacity %d but actual capacity was %d", n, actual)
+	}
+}
+
 // Function panics runs f and reports whether it panics.
 //
 // If f panics, panics returns true and the value passed to panic.
@@ -83,7 +413,72 @@ func MustPanic(t Reporter, f func()) any {
 	return with
 }
 
+// MustPanicWith runs f and verifies that it panics with the value want.
+//
+// The value passed to panic is compared to want as by ExpectEqual. If f does not panic,
+// or panics with a different value, MustPanicWith terminates the running test with an error.
+func MustPanicWith(t Reporter, want any, f func()) {
+	t.Helper()
+	panicked, with := panics(f)
+	if !panicked {
+		t.Fatal("Expected panic did not occur")
+	} else if !equal(want, with) {
+		t.Errorf("Expected panic: %#v", want)
+		t.Errorf("Actual panic:   %#v", with)
+		t.FailNow()
+	}
+}
+
+// MustPanicError runs f, verifies that it panics with an error, and returns the error.
+//
+// If f does not panic, or panics with a value that is not an error, MustPanicError
+// terminates the running test with an error; if the test is not terminated, it returns nil.
+func MustPanicError(t Reporter, f func()) error {
+	t.Helper()
+	panicked, with := panics(f)
+	if !panicked {
+		t.Fatal("Expected panic did not occur")
+		return nil
+	}
+	e, ok := with.(error)
+	if !ok {
+		t.Fatalf("Expected panic with an error but panic value was %#v", with)
+	}
+	return e
+}
+
+// MustNotPanic runs f and verifies that it does not panic.
+//
+// If f panics, the panic is recovered, and MustNotPanic terminates the running test
+// with an error reporting the value passed to panic.
+func MustNotPanic(t Reporter, f func()) {
+	t.Helper()
+	if panicked, with := panics(f); panicked {
+		t.Fatal("Unexpected panic:", with)
+	}
+}
+
+// ExpectPanicMessage runs f, verifies that it panics, and returns the value
+// passed to panic, formatted as if by fmt.Sprint.
+//
+// This is convenient when the panic value is an error or another type that is
+// awkward to compare directly. If f does not panic, ExpectPanicMessage terminates
+// the running test with an error; if the test is not terminated, it returns "".
+func ExpectPanicMessage(t Reporter, f func()) string {
+	t.Helper()
+	panicked, with := panics(f)
+	if !panicked {
+		t.Fatal("Expected panic did not occur")
+		return ""
+	}
+	return fmt.Sprint(with)
+}
+
 // NotFatal wraps a Reporter, and redirects fatal errors to non-terminating errors.
+//
+// Only FailNow, Fatal, and Fatalf are redirected. Other methods, such as Cleanup,
+// are forwarded to the wrapped Reporter unchanged. In particular, skipping a test
+// is not a failure, so SkipNow, Skip, and Skipf still terminate the test.
 type NotFatal struct {
 	Reporter
 }
@@ -105,3 +500,41 @@ func (nf NotFatal) Fatal(args ...any) {
 func (nf NotFatal) Fatalf(format string, args ...any) {
 	nf.Errorf(format, args...)
 }
+
+// NotFatal.SkipNow marks a test skipped and terminates it;
+// it is equivalent to nf.Reporter.SkipNow().
+func (nf NotFatal) SkipNow() {
+	nf.Reporter.SkipNow()
+}
+
+// NotFatal.Skip logs a message, marks a test skipped, and terminates it;
+// it is equivalent to nf.Reporter.Skip(args...).
+func (nf NotFatal) Skip(args ...any) {
+	nf.Reporter.Skip(args...)
+}
+
+// NotFatal.Skipf logs a message, marks a test skipped, and terminates it;
+// it is equivalent to nf.Reporter.Skipf(format, args...).
+func (nf NotFatal) Skipf(format string, args ...any) {
+	nf.Reporter.Skipf(format, args...)
+}
+
+// Strict wraps a Reporter, and redirects non-terminating errors to fatal errors,
+// so that the first error reported terminates the test. It is the opposite of NotFatal.
+//
+// Only Error and Errorf are redirected; Fail still marks a test failed without terminating it.
+type Strict struct {
+	Reporter
+}
+
+// Strict.Error reports an error and terminates the test;
+// it is equivalent to s.Fatal(args...).
+func (s Strict) Error(args ...any) {
+	s.Fatal(args...)
+}
+
+// Strict.Errorf reports an error and terminates the test;
+// it is equivalent to s.Fatalf(format, args...).
+func (s Strict) Errorf(format string, args ...any) {
+	s.Fatalf(format, args...)
+}
@@ -4,11 +4,17 @@
 package gotest
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestRequire(t *testing.T) {
@@ -50,6 +56,390 @@ func Try(t *testing.T) {
 	cmd.Run(t, "")
 }
 
+func TestExpectf(t *testing.T) {
+	var st StubReporter
+	Expectf(&st, 5, 5, "row %d", 1)
+	st.Expect(t, false, false, "")
+
+	Expectf(&st, "a", "b", "row %d (%s)", 2, "letters")
+	st.Expect(t, true, true, "row 2 (letters): Expected a but actual value was b\n")
+}
+
+func TestExpectNotEqual(t *testing.T) {
+	var st StubReporter
+	ExpectNotEqual(&st, "old", "new")
+	st.Expect(t, false, false, "")
+
+	ExpectNotEqual(&st, 3, 3)
+	st.Expect(t, true, true, "Expected values to differ, but both were 3\n")
+}
+
+func TestExpectClose(t *testing.T) {
+	var st StubReporter
+	ExpectClose(&st, 0.3, 0.1+0.2, 1e-9)
+	st.Expect(t, false, false, "")
+	ExpectClose(&st, 1, 1.5, 0.5)
+	st.Expect(t, false, false, "")
+	ExpectClose(&st, math.Inf(1), math.Inf(1), 0)
+	st.Expect(t, false, false, "")
+
+	ExpectClose(&st, 1, 1.25, 0.1)
+	st.Expect(t, true, true, "Expected 1 within 0.1 but actual value was 1.25 (difference 0.25)\n")
+
+	st.Reset()
+	ExpectClose(&st, math.NaN(), math.NaN(), 1)
+	st.Expect(t, true, true, "Expected NaN but actual value was NaN; NaN is not close to any value\n")
+
+	st.Reset()
+	ExpectClose(&st, math.Inf(1), math.Inf(-1), math.Inf(1))
+	st.Expect(t, true, true, "Expected +Inf within +Inf but actual value was -Inf (difference +Inf)\n")
+}
+
+func TestExpectCloseRel(t *testing.T) {
+	var st StubReporter
+	ExpectCloseRel(&st, 1e20, 1e20+1e10, 1e-9)
+	st.Expect(t, false, false, "")
+	ExpectCloseRel(&st, 0, 0, 0)
+	st.Expect(t, false, false, "")
+
+	ExpectCloseRel(&st, 100, 125, 0.1)
+	st.Expect(t, true, true, "Expected 100 within relative tolerance 0.1 but actual value was 125 (relative difference 0.2)\n")
+
+	st.Reset()
+	ExpectCloseRel(&st, 1, math.NaN(), 1)
+	st.Expect(t, true, true, "Expected 1 but actual value was NaN; NaN is not close to any value\n")
+
+	st.Reset()
+	ExpectCloseRel(&st, math.Inf(1), 1, 1)
+	Expect(t, true, st.Killed())
+}
+
+func TestExpectEqual(t *testing.T) {
+	var st StubReporter
+	ExpectEqual(&st, 5, 5)
+	st.Expect(t, false, false, "")
+	ExpectEqual(&st, []int{1, 2}, []int{1, 2})
+	st.Expect(t, false, false, "")
+	ExpectEqual(&st, map[string][]int{"a": {1}}, map[string][]int{"a": {1}})
+	st.Expect(t, false, false, "")
+	ExpectEqual(&st, nil, nil)
+	st.Expect(t, false, false, "")
+	type holder struct{ x any }
+	ExpectEqual(&st, holder{[]int{1}}, holder{[]int{1}})
+	st.Expect(t, false, false, "")
+
+	ExpectEqual(&st, []string{"a"}, []string{"b"})
+	st.Expect(t, true, true, `Expected: []string{"a"}
+Actual:   []string{"b"}
+first difference at index 0: expected "a" but actual value was "b"
+`)
+
+	st.Reset()
+	ExpectEqual(&st, []int{1, 2}, []int{1, 2, 3})
+	st.Expect(t, true, true, `Expected: []int{1, 2}
+Actual:   []int{1, 2, 3}
+lengths differ: expected 2 but actual length was 3
+`)
+
+	st.Reset()
+	ExpectEqual(&st, map[string]int{"a": 1, "b": 2, "c": 3}, map[string]int{"a": 1, "b": 5})
+	st.Expect(t, true, true, `Expected: map[string]int{"a":1, "b":2, "c":3}
+Actual:   map[string]int{"a":1, "b":5}
+first difference at key "b": expected 2 but actual value was 5
+`)
+
+	st.Reset()
+	ExpectEqual(&st, map[int]bool{1: true}, map[int]bool{1: true, 2: false})
+	st.Expect(t, true, true, `Expected: map[int]bool{1:true}
+Actual:   map[int]bool{1:true, 2:false}
+first difference at key 2: unexpected
+`)
+
+	st.Reset()
+	type pair struct {
+		name  string
+		count int
+	}
+	ExpectEqual(&st, pair{"x", 1}, pair{"x", 2})
+	st.Expect(t, true, true, `Expected: gotest.pair{name:"x", count:1}
+Actual:   gotest.pair{name:"x", count:2}
+differences:
+@@ -1,2 +1,2 @@
+ name: "x"
+-count: 1
++count: 2
+`)
+
+	st.Reset()
+	ExpectEqual(&st, "one\ntwo\n", "one\nthree\n")
+	st.Expect(t, true, true, `Expected: "one\ntwo\n"
+Actual:   "one\nthree\n"
+differences:
+@@ -1,2 +1,2 @@
+ one
+-two
++three
+`)
+
+	st.Reset()
+	ExpectEqual(&st, 1, int64(1))
+	st.Expect(t, true, true, `Expected: 1
+Actual:   1
+types differ: int, int64
+`)
+
+	st.Reset()
+	a, b := 1, 1
+	ExpectEqual(&st, &a, &b)
+	Expect(t, true, st.Killed())
+
+	st.Reset()
+	ExpectEqual(&st, nil, 0)
+	st.Expect(t, true, true, `Expected: <nil>
+Actual:   0
+`)
+}
+
+func TestExpectDeep(t *testing.T) {
+	var st StubReporter
+	ExpectDeep(&st, []int{1, 2}, []int{1, 2})
+	st.Expect(t, false, false, "")
+	ExpectDeep(&st, map[string]string{"a": "b"}, map[string]string{"a": "b"})
+	st.Expect(t, false, false, "")
+	a, b := 1, 1
+	ExpectDeep(&st, &a, &b)
+	st.Expect(t, false, false, "")
+
+	ExpectDeep(&st, map[string]int{"a": 1}, map[string]int{"a": 2})
+	st.Expect(t, true, true, `Expected: map[string]int{"a":1}
+Actual:   map[string]int{"a":2}
+`)
+
+	st.Reset()
+	ExpectDeep(&st, []int{1}, []int64{1})
+	st.Expect(t, true, true, `Expected: []int{1}
+Actual:   []int64{1}
+`)
+}
+
+func TestExpectEqualNormalizingSpace(t *testing.T) {
+	var st StubReporter
+	ExpectEqualNormalizingSpace(&st, "", " \n\t ")
+	st.Expect(t, false, false, "")
+	ExpectEqualNormalizingSpace(&st, "a b\nc", "  a\t b   c\n")
+	st.Expect(t, false, false, "")
+	ExpectEqualNormalizingSpace(&st, "name  size\nfoo   12\n", "name size\nfoo 12")
+	st.Expect(t, false, false, "")
+
+	ExpectEqualNormalizingSpace(&st, "the quick  fox\n", "the\tslow fox")
+	st.Expect(t, true, true, `Expected "the quick fox" but actual value was "the slow fox" (normalizing white space)
+differing words:
+@@ -1,3 +1,3 @@
+ the
+-quick
++slow
+ fox
+`)
+
+	st.Reset()
+	ExpectEqualNormalizingSpace(&st, "ab", "a b")
+	st.Expect(t, true, true, `Expected "ab" but actual value was "a b" (normalizing white space)
+differing words:
+@@ -1,1 +1,2 @@
+-ab
++a
++b
+`)
+}
+
+func TestExpectStable(t *testing.T) {
+	var st StubReporter
+	calls := 0
+	ExpectStable(&st, 5, func() string {
+		calls++
+		return "same"
+	})
+	st.Expect(t, false, false, "")
+	Expect(t, 5, calls)
+
+	calls = 0
+	ExpectStable(&st, 0, func() int {
+		calls++
+		return calls
+	})
+	st.Expect(t, false, false, "")
+	Expect(t, 0, calls)
+
+	ExpectStable(&st, 5, func() int {
+		calls++
+		return calls / 3
+	})
+	st.Expect(t, true, true, "Call 3 returned 1 but first call returned 0\n")
+	Expect(t, 3, calls)
+
+	st.Reset()
+	calls = 0
+	ExpectStable(NotFatal{&st}, 5, func() int {
+		calls++
+		return calls
+	})
+	st.Expect(t, true, false, "Call 2 returned 2 but first call returned 1\n")
+	Expect(t, 2, calls)
+}
+
+func TestExpectRoundTrip(t *testing.T) {
+	type pair struct {
+		A int
+		B []string
+	}
+	marshal := func(p pair) ([]byte, error) {
+		return json.Marshal(p)
+	}
+	unmarshal := func(data []byte) (p pair, e error) {
+		e = json.Unmarshal(data, &p)
+		return
+	}
+
+	var st StubReporter
+	ExpectRoundTrip(&st, pair{3, []string{"x", "y"}}, marshal, unmarshal)
+	st.Expect(t, false, false, "")
+
+	ExpectRoundTrip(&st, pair{3, []string{}}, marshal, unmarshal)
+	st.Expect(t, false, false, "")
+
+	ExpectRoundTrip(&st, pair{3, nil}, func(p pair) ([]byte, error) {
+		return []byte(`{"A":4}`), nil
+	}, unmarshal)
+	st.Expect(t, true, true, `Round trip produced gotest.pair{A:4, B:[]string(nil)}; expected gotest.pair{A:3, B:[]string(nil)}
+intermediate bytes: "{\"A\":4}"
+`)
+
+	st.Reset()
+	ExpectRoundTrip(&st, pair{}, func(p pair) ([]byte, error) {
+		return nil, errors.New("no can do")
+	}, unmarshal)
+	st.Expect(t, true, true, "Marshaling gotest.pair{A:0, B:[]string(nil)} failed: no can do\n")
+
+	st.Reset()
+	ExpectRoundTrip(&st, pair{}, func(p pair) ([]byte, error) {
+		return []byte("{"), nil
+	}, unmarshal)
+	st.Expect(t, true, true, "Unmarshaling \"{\" failed: unexpected end of JSON input\n")
+}
+
+func TestExpectImplements(t *testing.T) {
+	var st StubReporter
+	ExpectImplements[Reporter](&st, &st)
+	st.Expect(t, false, false, "")
+	ExpectImplements[fmt.Stringer](&st, time.Second)
+	st.Expect(t, false, false, "")
+	ExpectImplements[any](&st, 3)
+	st.Expect(t, false, false, "")
+
+	ExpectImplements[fmt.Stringer](&st, 3)
+	st.Expect(t, true, true, "int does not implement fmt.Stringer\n")
+
+	st.Reset()
+	ExpectImplements[Reporter](&st, nil)
+	st.Expect(t, true, true, "<nil> does not implement gotest.Reporter\n")
+
+	msg := MustPanic(t, func() {
+		ExpectImplements[int](&st, 3)
+	})
+	Expect(t, "gotest.ExpectImplements: int is not an interface type", msg.(string))
+}
+
+func TestExpectFitsIn(t *testing.T) {
+	var st StubReporter
+	Expect(t, int8(-128), ExpectFitsIn[int8](&st, -128))
+	Expect(t, int8(127), ExpectFitsIn[int8](&st, 127))
+	Expect(t, uint8(255), ExpectFitsIn[uint8](&st, 255))
+	Expect(t, int64(math.MinInt64), ExpectFitsIn[int64](&st, math.MinInt64))
+	Expect(t, uint64(math.MaxInt64), ExpectFitsIn[uint64](&st, math.MaxInt64))
+	Expect(t, int32(-5), ExpectFitsIn[int32](&st, -5))
+	st.Expect(t, false, false, "")
+
+	ExpectFitsIn[int8](&st, 128)
+	st.Expect(t, true, true, "128 does not fit in int8 (range -128 to 127)\n")
+
+	st.Reset()
+	ExpectFitsIn[int16](&st, -40000)
+	st.Expect(t, true, true, "-40000 does not fit in int16 (range -32768 to 32767)\n")
+
+	st.Reset()
+	ExpectFitsIn[uint32](&st, 1<<32)
+	st.Expect(t, true, true, "4294967296 does not fit in uint32 (range 0 to 4294967295)\n")
+
+	st.Reset()
+	ExpectFitsIn[uint64](&st, -1)
+	st.Expect(t, true, true, "-1 does not fit in uint64 (range 0 to 18446744073709551615)\n")
+
+	st.Reset()
+	type small uint8
+	ExpectFitsIn[small](&st, -1)
+	st.Expect(t, true, true, "-1 does not fit in gotest.small (range 0 to 255)\n")
+}
+
+func TestExpectSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	if e := os.WriteFile(path, []byte("twelve bytes"), 0666); e != nil {
+		t.Fatal(e)
+	}
+
+	var st StubReporter
+	ExpectSize(&st, 12, path)
+	st.Expect(t, false, false, "")
+
+	ExpectSize(&st, 13, path)
+	st.Expect(t, true, true, "Expected size 13 but actual size of "+path+" was 12\n")
+
+	st.Reset()
+	missing := filepath.Join(t.TempDir(), "missing")
+	ExpectSize(&st, 0, missing)
+	Require(t, st.Killed())
+	Require(t, strings.Contains(st.Logged(), missing))
+
+	st.Reset()
+	ExpectSize(NotFatal{&st}, 0, missing)
+	Expect(t, true, st.Failed())
+	Expect(t, 1, strings.Count(st.Logged(), "\n"))
+}
+
+func TestExpectLenBytes(t *testing.T) {
+	var st StubReporter
+	ExpectLenBytes(&st, 2, "é")
+	st.Expect(t, false, false, "")
+
+	ExpectLenBytes(&st, 1, "é")
+	st.Expect(t, true, true, "Expected length 1 bytes but actual length was 2\n")
+}
+
+func TestExpectChanLen(t *testing.T) {
+	ch := make(chan string, 3)
+	var st StubReporter
+	ExpectChanLen(&st, ch, 0)
+	st.Expect(t, false, false, "")
+
+	ch <- "a"
+	ch <- "b"
+	ExpectChanLen(&st, ch, 2)
+	st.Expect(t, false, false, "")
+
+	ExpectChanLen(&st, ch, 3)
+	st.Expect(t, true, true, "Expected channel length 3 but actual length was 2\n")
+}
+
+func TestExpectChanCap(t *testing.T) {
+	var st StubReporter
+	ExpectChanCap(&st, make(chan int, 5), 5)
+	st.Expect(t, false, false, "")
+	ExpectChanCap(&st, make(chan int), 0)
+	st.Expect(t, false, false, "")
+
+	ExpectChanCap(&st, make(chan int), 1)
+	st.Expect(t, true, true, "Expected channel capacity 1 but actual capacity was 0\n")
+}
+
 func TestPanics(t *testing.T) {
 	p, w := panics(func() {})
 	Expect(t, false, p)
@@ -85,6 +475,71 @@ func TestMustPanic(t *testing.T) {
 	Require(t, x == nil)
 }
 
+func TestMustPanicWith(t *testing.T) {
+	var st StubReporter
+	MustPanicWith(&st, "oops", func() { panic("oops") })
+	st.Expect(t, false, false, "")
+	MustPanicWith(&st, []int{1}, func() { panic([]int{1}) })
+	st.Expect(t, false, false, "")
+
+	MustPanicWith(&st, "oops", func() {})
+	st.Expect(t, true, true, "Expected panic did not occur\n")
+
+	st.Reset()
+	MustPanicWith(&st, "oops", func() { panic(fmt.Sprint("oops", 2)) })
+	st.Expect(t, true, true, `Expected panic: "oops"
+Actual panic:   "oops2"
+`)
+}
+
+func TestMustPanicError(t *testing.T) {
+	var st StubReporter
+	e := MustPanicError(&st, func() { panic(io.EOF) })
+	st.Expect(t, false, false, "")
+	Expect(t, io.EOF, e)
+
+	e = MustPanicError(&st, func() {})
+	st.Expect(t, true, true, "Expected panic did not occur\n")
+	Expect(t, nil, e)
+
+	st.Reset()
+	e = MustPanicError(&st, func() { panic("oops") })
+	st.Expect(t, true, true, "Expected panic with an error but panic value was \"oops\"\n")
+	Expect(t, nil, e)
+}
+
+func TestMustNotPanic(t *testing.T) {
+	var st StubReporter
+	ran := false
+	MustNotPanic(&st, func() { ran = true })
+	st.Expect(t, false, false, "")
+	Require(t, ran)
+
+	MustNotPanic(&st, func() {
+		panic(errors.New("oops"))
+	})
+	st.Expect(t, true, true, "Unexpected panic: oops\n")
+}
+
+func TestExpectPanicMessage(t *testing.T) {
+	var st StubReporter
+	msg := ExpectPanicMessage(&st, func() {
+		panic(errors.New("broken"))
+	})
+	st.Expect(t, false, false, "")
+	Expect(t, "broken", msg)
+
+	msg = ExpectPanicMessage(&st, func() {
+		panic([]int{1, 2})
+	})
+	st.Expect(t, false, false, "")
+	Expect(t, "[1 2]", msg)
+
+	msg = ExpectPanicMessage(&st, func() {})
+	st.Expect(t, true, true, "Expected panic did not occur\n")
+	Expect(t, "", msg)
+}
+
 func TestNotFatal(t *testing.T) {
 	var st1, st2, st3 StubReporter
 	NotFatal{&st1}.FailNow()
@@ -96,3 +551,37 @@ func TestNotFatal(t *testing.T) {
 	NotFatal{&st3}.Fatalf("<%s>", "uh oh")
 	st3.Expect(t, true, false, "<uh oh>\n")
 }
+
+func TestNotFatalSkip(t *testing.T) {
+	var st StubReporter
+	NotFatal{&st}.SkipNow()
+	Expect(t, true, st.Skipped())
+	st.Expect(t, false, false, "")
+
+	st.Reset()
+	NotFatal{&st}.Skip("not", "today")
+	Expect(t, true, st.Skipped())
+	st.Expect(t, false, false, "not today\n")
+
+	st.Reset()
+	NotFatal{&st}.Skipf("need %d", 2)
+	Expect(t, true, st.Skipped())
+	st.Expect(t, false, false, "need 2\n")
+}
+
+func TestStrict(t *testing.T) {
+	var st1, st2, st3 StubReporter
+	Strict{&st1}.Error("x")
+	st1.Expect(t, true, true, "x\n")
+
+	Strict{&st2}.Errorf("<%s>", "y")
+	st2.Expect(t, true, true, "<y>\n")
+
+	Strict{&st3}.Fail()
+	st3.Expect(t, true, false, "")
+
+	st3.Reset()
+	ExpectEqual(Strict{&st3}, 1, 2)
+	Expect(t, true, st3.Killed())
+	Expect(t, true, strings.HasPrefix(st3.Logged(), "Expected: 1\n"))
+}
@@ -0,0 +1,191 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+)
+
+// ExpectJSONEqualApprox verifies that expected and actual hold equivalent JSON values,
+// allowing numbers to differ by up to tolerance.
+//
+// The values are compared structurally, so differences in whitespace and in the order of
+// object members do not matter. Numbers with the same value, such as 1 and 1.0, are equal,
+// even when they are too large or precise to be represented exactly as float64; numbers
+// within tolerance of each other, compared as float64, are also considered equal.
+// All other values must match exactly. Each difference is reported along with its
+// path within the values, such as $.items[2].price.
+func ExpectJSONEqualApprox(t Reporter, expected, actual string, tolerance float64) {
+	t.Helper()
+	e, err := decodeJSON([]byte(expected))
+	if err != nil {
+		t.Fatalf("Invalid expected JSON: %v", err)
+		return // In case t.Fatalf has been overridden to not terminate the test case.
+	}
+	a, err := decodeJSON([]byte(actual))
+	if err != nil {
+		t.Fatalf("Invalid actual JSON: %v", err)
+		return
+	}
+
+	ok := true
+	jsonDiff("$", e, a, tolerance, func(msg string) {
+		t.Error(msg)
+		ok = false
+	})
+	if !ok {
+		t.FailNow()
+	}
+}
+
+// ExpectMarshalsTo verifies that v, marshaled by encoding/json, is equivalent to expectedJSON.
+//
+// The comparison is structural, as for ExpectJSONEqualApprox with zero tolerance,
+// so differences in whitespace and the order of object members do not matter.
+// On failure, the actual JSON and each difference are reported.
+func ExpectMarshalsTo(t Reporter, v any, expectedJSON string) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshaling %#v failed: %v", v, err)
+		return // In case t.Fatalf has been overridden to not terminate the test case.
+	}
+	e, err := decodeJSON([]byte(expectedJSON))
+	if err != nil {
+		t.Fatalf("Invalid expected JSON: %v", err)
+		return
+	}
+	a, err := decodeJSON(data)
+	if err != nil {
+		// Should be impossible
+		panic(err)
+	}
+
+	ok := true
+	jsonDiff("$", e, a, 0, func(msg string) {
+		if ok {
+			t.Errorf("actual JSON: %s", data)
+			ok = false
+		}
+		t.Error(msg)
+	})
+	if !ok {
+		t.FailNow()
+	}
+}
+
+// Function decodeJSON decodes a JSON value, representing numbers as json.Number
+// so that no precision is lost.
+func decodeJSON(data []byte) (any, error) {
+	// Unmarshaling into a RawMessage checks the syntax of the whole input,
+	// with the same error messages as unmarshaling into any.
+	var raw json.RawMessage
+	if e := json.Unmarshal(data, &raw); e != nil {
+		return nil, e
+	}
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	var v any
+	e := d.Decode(&v)
+	return v, e
+}
+
+// Function jsonDiff compares two decoded JSON values, calling report with a
+// description of each difference found. The path identifies the values being compared.
+func jsonDiff(path string, expected, actual any, tolerance float64, report func(string)) {
+	switch e := expected.(type) {
+	case json.Number:
+		if a, ok := actual.(json.Number); ok {
+			if numbersEqual(e, a) {
+				return
+			}
+			if tolerance > 0 {
+				ef, _ := e.Float64()
+				af, _ := a.Float64()
+				if !(math.Abs(af-ef) <= tolerance) {
+					report(fmt.Sprintf("%s: expected %v but actual value was %v (difference %v exceeds tolerance %v)",
+						path, e, a, math.Abs(af-ef), tolerance))
+				}
+				return
+			}
+		}
+
+	case []any:
+		if a, ok := actual.([]any); ok {
+			if len(a) != len(e) {
+				report(fmt.Sprintf("%s: expected %d elements but actual length was %d", path, len(e), len(a)))
+				return
+			}
+			for i := range e {
+				jsonDiff(fmt.Sprintf("%s[%d]", path, i), e[i], a[i], tolerance, report)
+			}
+			return
+		}
+
+	case map[string]any:
+		if a, ok := actual.(map[string]any); ok {
+			keys := make([]string, 0, len(e)+len(a))
+			for k := range e {
+				keys = append(keys, k)
+			}
+			for k := range a {
+				if _, found := e[k]; !found {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				ev, inE := e[k]
+				av, inA := a[k]
+				switch {
+				case !inA:
+					report(fmt.Sprintf("%s.%s: missing", path, k))
+				case !inE:
+					report(fmt.Sprintf("%s.%s: unexpected", path, k))
+				default:
+					jsonDiff(path+"."+k, ev, av, tolerance, report)
+				}
+			}
+			return
+		}
+
+	default:
+		// nil, bool, or string
+		if expected == actual {
+			return
+		}
+	}
+
+	report(fmt.Sprintf("%s: expected %s but actual value was %s", path, jsonText(expected), jsonText(actual)))
+}
+
+// Function numbersEqual reports whether two JSON numbers have exactly the same value.
+func numbersEqual(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+	var ar, br big.Rat
+	if _, ok := ar.SetString(string(a)); !ok {
+		return false
+	}
+	if _, ok := br.SetString(string(b)); !ok {
+		return false
+	}
+	return ar.Cmp(&br) == 0
+}
+
+// Function jsonText returns the JSON encoding of a decoded JSON value.
+func jsonText(v any) string {
+	data, e := json.Marshal(v)
+	if e != nil {
+		// Should be impossible
+		panic(e)
+	}
+	return string(data)
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpectJSONEqualApprox(t *testing.T) {
+	var st StubReporter
+	ExpectJSONEqualApprox(&st, `{"a": 1, "b": [true, null, "s"]}`, `{"b":[true,null,"s"],"a":1.0}`, 0)
+	st.Expect(t, false, false, "")
+
+	ExpectJSONEqualApprox(&st, `{"x": [0.1, 2e3]}`, `{"x": [0.10000001, 2000.0001]}`, 0.001)
+	st.Expect(t, false, false, "")
+
+	ExpectJSONEqualApprox(&st, `{"x": [0.1, 2e3]}`, `{"x": [0.2, 2000]}`, 0.05)
+	Expect(t, true, st.Killed())
+	Require(t, strings.HasPrefix(st.Logged(), "$.x[0]: expected 0.1 but actual value was 0.2 (difference "))
+	Require(t, strings.HasSuffix(st.Logged(), " exceeds tolerance 0.05)\n"))
+
+	st.Reset()
+	ExpectJSONEqualApprox(&st, `{"a": 1, "b": "s", "c": [1, 2], "d": {}}`, `{"b": "t", "c": [1], "d": 5, "e": null}`, 0)
+	st.Expect(t, true, true, `$.a: missing
+$.b: expected "s" but actual value was "t"
+$.c: expected 2 elements but actual length was 1
+$.d: expected {} but actual value was 5
+$.e: unexpected
+`)
+
+	// Numbers beyond the precision of float64 are compared exactly.
+	st.Reset()
+	ExpectJSONEqualApprox(&st, `[1152921504606846977, 1e2]`, `[1152921504606846977, 100.0]`, 0)
+	st.Expect(t, false, false, "")
+	ExpectJSONEqualApprox(&st, `1152921504606846977`, `1152921504606846976`, 0)
+	st.Expect(t, true, true, "$: expected 1152921504606846977 but actual value was 1152921504606846976\n")
+
+	st.Reset()
+	ExpectJSONEqualApprox(&st, `1`, `"1"`, 1)
+	st.Expect(t, true, true, "$: expected 1 but actual value was \"1\"\n")
+
+	st.Reset()
+	ExpectJSONEqualApprox(&st, `[1`, `[1]`, 0)
+	st.Expect(t, true, true, "Invalid expected JSON: unexpected end of JSON input\n")
+
+	st.Reset()
+	ExpectJSONEqualApprox(&st, `[1]`, `]`, 0)
+	st.Expect(t, true, true, "Invalid actual JSON: invalid character ']' looking for beginning of value\n")
+}
+
+func TestExpectMarshalsTo(t *testing.T) {
+	type item struct {
+		Name  string   `json:"name"`
+		Tags  []string `json:"tags,omitempty"`
+		Price float64  `json:"price"`
+	}
+
+	var st StubReporter
+	ExpectMarshalsTo(&st, item{"pen", []string{"blue"}, 1.5}, `{"price": 1.5, "tags": ["blue"], "name": "pen"}`)
+	st.Expect(t, false, false, "")
+	ExpectMarshalsTo(&st, item{Name: "cap"}, `{"name":"cap","price":0}`)
+	st.Expect(t, false, false, "")
+
+	ExpectMarshalsTo(&st, item{Name: "cap"}, `{"name":"hat","price":0,"tags":[]}`)
+	st.Expect(t, true, true, `actual JSON: {"name":"cap","price":0}
+$.name: expected "hat" but actual value was "cap"
+$.tags: missing
+`)
+
+	st.Reset()
+	type record struct{ ID int64 }
+	ExpectMarshalsTo(&st, record{1<<60 + 1}, `{"ID": 1152921504606846977}`)
+	st.Expect(t, false, false, "")
+	ExpectMarshalsTo(&st, record{1<<60 + 1}, `{"ID": 1152921504606846976}`)
+	st.Expect(t, true, true, `actual JSON: {"ID":1152921504606846977}
+$.ID: expected 1152921504606846976 but actual value was 1152921504606846977
+`)
+
+	st.Reset()
+	ExpectMarshalsTo(&st, make(chan int), `{}`)
+	Expect(t, true, st.Killed())
+	Require(t, strings.HasPrefix(st.Logged(), "Marshaling (chan int)(0x"))
+	Require(t, strings.HasSuffix(st.Logged(), " failed: json: unsupported type: chan int\n"))
+
+	st.Reset()
+	ExpectMarshalsTo(&st, 1, `{`)
+	st.Expect(t, true, true, "Invalid expected JSON: unexpected end of JSON input\n")
+}
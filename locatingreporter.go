@@ -0,0 +1,87 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+)
+
+// Type *LocatingStubReporter is a StubReporter that also records where errors were reported.
+//
+// Each call to Error, Errorf, Fatal, or Fatalf records the source location, in the form
+// file:line, of the code that reported the error. As in the testing package, functions that
+// have called Helper are skipped, so the location is that of the helper's caller.
+// This allows tests of helper functions to verify where failures are attributed.
+type LocatingStubReporter struct {
+	StubReporter
+	helpers   map[string]bool
+	locations []string
+}
+
+// Helper marks the calling function as a helper function.
+//
+// Locations recorded afterward skip over that function.
+func (lr *LocatingStubReporter) Helper() {
+	var pc [1]uintptr
+	if runtime.Callers(2, pc[:]) == 0 {
+		return
+	}
+	frame, _ := runtime.CallersFrames(pc[:]).Next()
+	if lr.helpers == nil {
+		lr.helpers = make(map[string]bool)
+	}
+	lr.helpers[frame.Function] = true
+}
+
+// Method record records the location of the caller of its caller, skipping helper functions.
+func (lr *LocatingStubReporter) record() {
+	var pcs [50]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !lr.helpers[frame.Function] || !more {
+			lr.locations = append(lr.locations, fmt.Sprintf("%s:%d", filepath.Base(frame.File), frame.Line))
+			return
+		}
+	}
+}
+
+// Locations returns the locations recorded for each error reported, in order.
+func (lr *LocatingStubReporter) Locations() []string {
+	return lr.locations
+}
+
+// Error records the caller's location and calls StubReporter.Error.
+func (lr *LocatingStubReporter) Error(args ...any) {
+	lr.record()
+	lr.StubReporter.Error(args...)
+}
+
+// Errorf records the caller's location and calls StubReporter.Errorf.
+func (lr *LocatingStubReporter) Errorf(format string, args ...any) {
+	lr.record()
+	lr.StubReporter.Errorf(format, args...)
+}
+
+// Fatal records the caller's location and calls StubReporter.Fatal.
+func (lr *LocatingStubReporter) Fatal(args ...any) {
+	lr.record()
+	lr.StubReporter.Fatal(args...)
+}
+
+// Fatalf records the caller's location and calls StubReporter.Fatalf.
+func (lr *LocatingStubReporter) Fatalf(format string, args ...any) {
+	lr.record()
+	lr.StubReporter.Fatalf(format, args...)
+}
+
+// Reset returns a LocatingStubReporter to the initial state,
+// except that functions marked as helpers remain marked.
+func (lr *LocatingStubReporter) Reset() {
+	lr.StubReporter.Reset()
+	lr.locations = nil
+}
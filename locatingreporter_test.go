@@ -0,0 +1,63 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+var _ Reporter = &LocatingStubReporter{}
+
+// Function previous returns the location of the line before its caller.
+func previous() string {
+	_, _, line, _ := runtime.Caller(1)
+	return fmt.Sprintf("locatingreporter_test.go:%d", line-1)
+}
+
+func helperReporting(t Reporter) {
+	t.Helper()
+	t.Error("from helper")
+}
+
+func nestedHelper(t Reporter) {
+	t.Helper()
+	helperReporting(t)
+}
+
+func notHelper(t Reporter) string {
+	t.Errorf("%s", "from non-helper")
+	return previous()
+}
+
+func TestLocatingStubReporter(t *testing.T) {
+	var lr LocatingStubReporter
+	Expect(t, 0, len(lr.Locations()))
+
+	lr.Error("direct")
+	loc1 := previous()
+	helperReporting(&lr)
+	loc2 := previous()
+	nestedHelper(&lr)
+	loc3 := previous()
+	loc4 := notHelper(&lr)
+	Expect(&lr, 1, 2)
+	loc5 := previous()
+	lr.Fatalf("%s", "fatal")
+	loc6 := previous()
+
+	Expect(t, strings.Join([]string{loc1, loc2, loc3, loc4, loc5, loc6}, " "), strings.Join(lr.Locations(), " "))
+	lr.Expect(t, true, true, "direct\nfrom helper\nfrom helper\nfrom non-helper\nExpected 1 but actual value was 2\nfatal\n")
+
+	lr.Reset()
+	lr.Expect(t, false, false, "")
+	Expect(t, 0, len(lr.Locations()))
+	helperReporting(&lr)
+	loc7 := previous()
+	lr.Fatal("again")
+	loc8 := previous()
+	Expect(t, loc7+" "+loc8, strings.Join(lr.Locations(), " "))
+}
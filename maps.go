@@ -0,0 +1,63 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ExpectMap verifies that actual has the same keys as expected, with the same values.
+//
+// On failure, the differences are reported in three groups: keys missing from actual,
+// extra keys in actual, and keys whose values differ. The keys in each group are listed
+// in order of their formatted text, and only the first few of each group are listed.
+func ExpectMap[K, V comparable](t Reporter, expected, actual map[K]V) {
+	t.Helper()
+	var missing, extra, changed []K
+	for k, ev := range expected {
+		if av, ok := actual[k]; !ok {
+			missing = append(missing, k)
+		} else if av != ev {
+			changed = append(changed, k)
+		}
+	}
+	for k := range actual {
+		if _, ok := expected[k]; !ok {
+			extra = append(extra, k)
+		}
+	}
+	if len(missing) == 0 && len(extra) == 0 && len(changed) == 0 {
+		return
+	}
+
+	t.Errorf("Expected map with %d keys; actual map has %d keys, of which %d missing, %d extra, %d changed",
+		len(expected), len(actual), len(missing), len(extra), len(changed))
+	reportKeys(t, "missing", missing, func(k K) string {
+		return fmt.Sprintf("missing key %v: expected %v", k, expected[k])
+	})
+	reportKeys(t, "extra", extra, func(k K) string {
+		return fmt.Sprintf("extra key %v: actual %v", k, actual[k])
+	})
+	reportKeys(t, "changed", changed, func(k K) string {
+		return fmt.Sprintf("changed key %v: expected %v, actual %v", k, expected[k], actual[k])
+	})
+	t.FailNow()
+}
+
+// Function reportKeys sorts keys by their formatted text, and reports the description
+// of each of the first few, followed by the number of keys not listed; kind describes the keys.
+func reportKeys[K comparable](t Reporter, kind string, keys []K, describe func(K) string) {
+	t.Helper()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+	})
+	for i, k := range keys {
+		if i == maxReportedDiffs {
+			t.Errorf("and %d more %s keys", len(keys)-i, kind)
+			break
+		}
+		t.Error(describe(k))
+	}
+}
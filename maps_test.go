@@ -0,0 +1,37 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import "testing"
+
+func TestExpectMap(t *testing.T) {
+	var st StubReporter
+	ExpectMap(&st, map[string]int{}, nil)
+	st.Expect(t, false, false, "")
+	ExpectMap(&st, map[string]int{"a": 1, "b": 2}, map[string]int{"b": 2, "a": 1})
+	st.Expect(t, false, false, "")
+
+	ExpectMap(&st, map[string]int{"a": 1, "b": 2, "c": 3}, map[string]int{"a": 1, "c": 4, "d": 5})
+	st.Expect(t, true, true, `Expected map with 3 keys; actual map has 3 keys, of which 1 missing, 1 extra, 1 changed
+missing key b: expected 2
+extra key d: actual 5
+changed key c: expected 3, actual 4
+`)
+
+	st.Reset()
+	expected := make(map[int]bool)
+	for i := 0; i < 8; i++ {
+		expected[i] = true
+	}
+	ExpectMap(&st, expected, map[int]bool{3: false})
+	st.Expect(t, true, true, `Expected map with 8 keys; actual map has 1 keys, of which 7 missing, 0 extra, 1 changed
+missing key 0: expected true
+missing key 1: expected true
+missing key 2: expected true
+missing key 4: expected true
+missing key 5: expected true
+and 2 more missing keys
+changed key 3: expected true, actual false
+`)
+}
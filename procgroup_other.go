@@ -0,0 +1,12 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+//go:build !unix
+
+package gotest
+
+import "os/exec"
+
+// Function killGroupOnCancel does nothing on this system; when the command's
+// context is done, only the command itself is killed.
+func killGroupOnCancel(cmd *exec.Cmd) {}
@@ -0,0 +1,24 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+//go:build unix
+
+package gotest
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Function killGroupOnCancel arranges for cmd to run in a new process group,
+// and for the whole group to be killed if the command's context is done.
+// This also stops any processes the command has started itself.
+func killGroupOnCancel(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = new(syscall.SysProcAttr)
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import "io"
+
+// Constant readChunk is the size of the pieces in which ExpectReadersEqual reads its inputs.
+const readChunk = 32 * 1024
+
+// Constant diffContext is the number of bytes shown on either side of the first difference
+// found by ExpectReadersEqual.
+const diffContext = 16
+
+// ExpectReadersEqual verifies that want and got produce the same bytes.
+//
+// The readers are consumed in chunks, so large streams can be compared without
+// holding them entirely in memory. On a mismatch, the offset of the first differing
+// byte is reported, along with a few bytes from each stream around that offset.
+// An error from either reader, other than io.EOF, is reported as a fatal error.
+func ExpectReadersEqual(t Reporter, want, got io.Reader) {
+	t.Helper()
+	wbuf := make([]byte, readChunk)
+	gbuf := make([]byte, readChunk)
+	var offset int64
+	for {
+		wn, we := io.ReadFull(want, wbuf)
+		gn, ge := io.ReadFull(got, gbuf)
+		for _, e := range []error{we, ge} {
+			if e != nil && e != io.EOF && e != io.ErrUnexpectedEOF {
+				t.Fatal(e)
+				return // In case t.Fatal has been overridden to not terminate the test case.
+			}
+		}
+		w, g := wbuf[:wn], gbuf[:gn]
+
+		i := 0
+		for i < len(w) && i < len(g) && w[i] == g[i] {
+			i++
+		}
+		if i < len(w) || i < len(g) {
+			start := max(0, i-diffContext)
+			switch {
+			case i == len(g):
+				t.Errorf("Readers differ at offset %d: got ended, but want continues", offset+int64(i))
+			case i == len(w):
+				t.Errorf("Readers differ at offset %d: want ended, but got continues", offset+int64(i))
+			default:
+				t.Errorf("Readers differ at offset %d", offset+int64(i))
+			}
+			t.Errorf("want from offset %d: %q", offset+int64(start), w[start:min(len(w), i+diffContext)])
+			t.Errorf("got from offset %d: %q", offset+int64(start), g[start:min(len(g), i+diffContext)])
+			t.FailNow()
+			return
+		}
+
+		if we != nil || ge != nil {
+			// Both readers have ended at the same point.
+			return
+		}
+		offset += int64(len(w))
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestExpectReadersEqual(t *testing.T) {
+	var st StubReporter
+	ExpectReadersEqual(&st, strings.NewReader(""), strings.NewReader(""))
+	st.Expect(t, false, false, "")
+
+	ExpectReadersEqual(&st, strings.NewReader("hello"), iotest.OneByteReader(strings.NewReader("hello")))
+	st.Expect(t, false, false, "")
+
+	big := strings.Repeat("0123456789", 10000)
+	ExpectReadersEqual(&st, strings.NewReader(big), iotest.HalfReader(strings.NewReader(big)))
+	st.Expect(t, false, false, "")
+
+	ExpectReadersEqual(&st, strings.NewReader("abcdef"), strings.NewReader("abcxef"))
+	st.Expect(t, true, true, `Readers differ at offset 3
+want from offset 0: "abcdef"
+got from offset 0: "abcxef"
+`)
+
+	st.Reset()
+	ExpectReadersEqual(&st, strings.NewReader("abc"), strings.NewReader("abcd"))
+	st.Expect(t, true, true, `Readers differ at offset 3: want ended, but got continues
+want from offset 0: "abc"
+got from offset 0: "abcd"
+`)
+
+	st.Reset()
+	changed := big[:50005] + "X" + big[50006:]
+	ExpectReadersEqual(&st, strings.NewReader(big), strings.NewReader(changed))
+	st.Expect(t, true, true, `Readers differ at offset 50005
+want from offset 49989: "90123456789012345678901234567890"
+got from offset 49989: "9012345678901234X678901234567890"
+`)
+
+	st.Reset()
+	ExpectReadersEqual(&st, strings.NewReader(big), strings.NewReader(big[:70000]))
+	st.Expect(t, true, true, `Readers differ at offset 70000: got ended, but want continues
+want from offset 69984: "45678901234567890123456789012345"
+got from offset 69984: "4567890123456789"
+`)
+
+	st.Reset()
+	ExpectReadersEqual(&st, strings.NewReader("abc"), io.MultiReader(strings.NewReader("ab"), iotest.ErrReader(errors.New("broken"))))
+	st.Expect(t, true, true, "broken\n")
+}
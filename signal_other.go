@@ -0,0 +1,17 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+//go:build !unix
+
+package gotest
+
+import "os"
+
+// Constant exitCodesLimited is false, since exit codes outside 0-255, such as
+// NTSTATUS values on Windows, are normal on this system.
+const exitCodesLimited = false
+
+// Function signalDescription can not identify signals on this system; it always returns "".
+func signalDescription(ps *os.ProcessState) string {
+	return ""
+}
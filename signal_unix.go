@@ -0,0 +1,53 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+//go:build unix
+
+package gotest
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Constant exitCodesLimited reports whether a process that exits normally can only
+// report an exit code in the range 0-255.
+const exitCodesLimited = true
+
+// Variable signalNames maps common signals to their conventional names.
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGALRM: "SIGALRM",
+	syscall.SIGBUS:  "SIGBUS",
+	syscall.SIGFPE:  "SIGFPE",
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGILL:  "SIGILL",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGPIPE: "SIGPIPE",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGSYS:  "SIGSYS",
+	syscall.SIGTERM: "SIGTERM",
+	syscall.SIGTRAP: "SIGTRAP",
+	syscall.SIGUSR1: "SIGUSR1",
+	syscall.SIGUSR2: "SIGUSR2",
+	syscall.SIGXCPU: "SIGXCPU",
+	syscall.SIGXFSZ: "SIGXFSZ",
+}
+
+// Function signalDescription describes the signal that terminated a process,
+// as in "segmentation fault (SIGSEGV)". It returns "" if the process was not
+// terminated by a signal.
+func signalDescription(ps *os.ProcessState) string {
+	ws, ok := ps.Sys().(syscall.WaitStatus)
+	if !ok || !ws.Signaled() {
+		return ""
+	}
+	sig := ws.Signal()
+	if name, ok := signalNames[sig]; ok {
+		return fmt.Sprintf("%v (%s)", sig, name)
+	}
+	return fmt.Sprintf("%v (signal %d)", sig, int(sig))
+}
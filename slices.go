@@ -0,0 +1,212 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import (
+	"cmp"
+	"reflect"
+)
+
+// Constant maxReportedDiffs is the number of differences reported individually
+// by ExpectSlice and ExpectMap; any further differences are only counted.
+const maxReportedDiffs = 5
+
+// ExpectSlice verifies that actual has the same elements as expected, in the same order.
+//
+// On failure, a difference in length is reported, followed by each index,
+// among those present in both slices, at which the elements differ, along with
+// the two elements. Only the first few differing indices are listed.
+func ExpectSlice[T comparable](t Reporter, expected, actual []T) {
+	t.Helper()
+	ok := true
+	if len(expected) != len(actual) {
+		t.Errorf("Expected slice of length %d but actual length was %d", len(expected), len(actual))
+		ok = false
+	}
+	diffs := 0
+	for i := 0; i < len(expected) && i < len(actual); i++ {
+		if expected[i] != actual[i] {
+			if diffs < maxReportedDiffs {
+				t.Errorf("index %d: expected %v, actual %v", i, expected[i], actual[i])
+			}
+			diffs++
+		}
+	}
+	if diffs > maxReportedDiffs {
+		t.Errorf("and %d more differing elements", diffs-maxReportedDiffs)
+	}
+	if !ok || diffs > 0 {
+		t.FailNow()
+	}
+}
+
+// ExpectPermutation verifies that got is a reordering of want.
+//
+// That is, each value must occur the same number of times in both slices.
+// If not, ExpectPermutation reports both slices and, for each value whose
+// number of occurrences differs, the two counts.
+func ExpectPermutation[T comparable](t Reporter, want, got []T) {
+	t.Helper()
+	counts := make(map[T]int)
+	var order []T
+	for _, x := range want {
+		if _, ok := counts[x]; !ok {
+			order = append(order, x)
+		}
+		counts[x]++
+	}
+	for _, x := range got {
+		if _, ok := counts[x]; !ok {
+			order = append(order, x)
+		}
+		counts[x]--
+	}
+
+	ok := true
+	for _, x := range order {
+		if counts[x] != 0 {
+			if ok {
+				t.Error("Expected a permutation of", want, "but actual value was", got)
+				ok = false
+			}
+			n := count(got, x)
+			t.Errorf("count of %v: expected %d, actual %d", x, n+counts[x], n)
+		}
+	}
+	if !ok {
+		t.FailNow()
+	}
+}
+
+// ExpectSubslice verifies that needle occurs as a contiguous run of elements within haystack.
+func ExpectSubslice[T comparable](t Reporter, haystack, needle []T) {
+	t.Helper()
+	if index(haystack, needle) < 0 {
+		t.Fatal("Expected", haystack, "to contain", needle)
+	}
+}
+
+// ExpectUnique verifies that no value occurs more than once in s.
+//
+// If there is a duplicate, ExpectUnique reports the first value found to be
+// repeated, and the indices of its first two occurrences.
+func ExpectUnique[T comparable](t Reporter, s []T) {
+	t.Helper()
+	seen := make(map[T]int, len(s))
+	for i, x := range s {
+		if j, ok := seen[x]; ok {
+			t.Fatal("Duplicate value", x, "at indices", j, "and", i)
+			return // In case t.Fatal has been overridden to not terminate the test case.
+		}
+		seen[x] = i
+	}
+}
+
+// ExpectPrefixSlice verifies that s begins with the elements of prefix.
+//
+// On failure, the corresponding leading elements of s are reported.
+func ExpectPrefixSlice[T comparable](t Reporter, prefix, s []T) {
+	t.Helper()
+	n := min(len(prefix), len(s))
+	if len(prefix) > len(s) || index(s[:n], prefix) != 0 {
+		t.Fatal("Expected prefix", prefix, "but actual slice began", s[:n])
+	}
+}
+
+// ExpectSuffixSlice verifies that s ends with the elements of suffix.
+//
+// On failure, the corresponding trailing elements of s are reported.
+func ExpectSuffixSlice[T comparable](t Reporter, suffix, s []T) {
+	t.Helper()
+	n := min(len(suffix), len(s))
+	if len(suffix) > len(s) || index(s[len(s)-n:], suffix) != 0 {
+		t.Fatal("Expected suffix", suffix, "but actual slice ended", s[len(s)-n:])
+	}
+}
+
+// ExpectMonotonic verifies that the elements of s are non-decreasing or,
+// if strict is true, strictly increasing.
+//
+// On failure, the first pair of adjacent elements out of order is reported,
+// with their indices.
+func ExpectMonotonic[T cmp.Ordered](t Reporter, s []T, strict bool) {
+	t.Helper()
+	for i := 1; i < len(s); i++ {
+		if c := cmp.Compare(s[i-1], s[i]); c > 0 || strict && c == 0 {
+			what := "decreasing"
+			if c == 0 {
+				what = "equal"
+			}
+			t.Fatalf("Elements at indices %d and %d are %s: %v, %v", i-1, i, what, s[i-1], s[i])
+			return // In case t.Fatalf has been overridden to not terminate the test case.
+		}
+	}
+}
+
+// ExpectStableSort verifies that sorted is the result of a stable sort of original by key.
+//
+// That is, the elements of sorted must be in non-decreasing order of key, and the elements
+// with any given key must be the same as those in original with that key, in the same order.
+// Elements are compared with reflect.DeepEqual. The first violation found is reported,
+// with the relevant indices.
+func ExpectStableSort[T any](t Reporter, original, sorted []T, key func(T) int) {
+	t.Helper()
+	if len(sorted) != len(original) {
+		t.Fatalf("Sorted slice has length %d but original has length %d", len(sorted), len(original))
+		return // In case t.Fatalf has been overridden to not terminate the test case.
+	}
+
+	// byKey[k] lists the indices in original of the elements with key k.
+	byKey := make(map[int][]int)
+	for i, x := range original {
+		k := key(x)
+		byKey[k] = append(byKey[k], i)
+	}
+
+	for i, x := range sorted {
+		k := key(x)
+		if i > 0 {
+			if prev := key(sorted[i-1]); prev > k {
+				t.Fatalf("Elements at indices %d and %d of sorted slice are out of order: keys %d, %d", i-1, i, prev, k)
+				return
+			}
+		}
+		if len(byKey[k]) == 0 {
+			t.Fatalf("Element at index %d of sorted slice has key %d, but original has no more elements with that key", i, k)
+			return
+		}
+		j := byKey[k][0]
+		byKey[k] = byKey[k][1:]
+		if !reflect.DeepEqual(x, original[j]) {
+			t.Fatalf("Element at index %d of sorted slice is %v; expected %v, from index %d of original", i, x, original[j], j)
+			return
+		}
+	}
+}
+
+// Function index returns the index of the first occurrence of needle in haystack,
+// or -1 if needle does not occur.
+func index[T comparable](haystack, needle []T) int {
+outer:
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		for j, x := range needle {
+			if haystack[i+j] != x {
+				continue outer
+			}
+		}
+		return i
+	}
+	return -1
+}
+
+// Function count returns the number of occurrences of x in s.
+func count[T comparable](s []T, x T) int {
+	n := 0
+	for _, y := range s {
+		if y == x {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 Patrick Smith
+// Use of this source code is subject to the MIT-style license in the LICENSE file.
+
+package gotest
+
+import "testing"
+
+func TestExpectSlice(t *testing.T) {
+	var st StubReporter
+	ExpectSlice(&st, []int{}, nil)
+	st.Expect(t, false, false, "")
+	ExpectSlice(&st, []string{"a", "b"}, []string{"a", "b"})
+	st.Expect(t, false, false, "")
+
+	ExpectSlice(&st, []string{"a", "b", "c"}, []string{"a", "x"})
+	st.Expect(t, true, true, `Expected slice of length 3 but actual length was 2
+index 1: expected b, actual x
+`)
+
+	st.Reset()
+	ExpectSlice(&st, []int{0, 1, 2, 3, 4, 5, 6, 7, 8}, []int{0, 10, 20, 30, 4, 50, 60, 70, 80})
+	st.Expect(t, true, true, `index 1: expected 1, actual 10
+index 2: expected 2, actual 20
+index 3: expected 3, actual 30
+index 5: expected 5, actual 50
+index 6: expected 6, actual 60
+and 2 more differing elements
+`)
+}
+
+func TestExpectPermutation(t *testing.T) {
+	var st StubReporter
+	ExpectPermutation(&st, []int{}, nil)
+	st.Expect(t, false, false, "")
+
+	ExpectPermutation(&st, []int{3, 1, 2, 1}, []int{1, 1, 2, 3})
+	st.Expect(t, false, false, "")
+
+	ExpectPermutation(&st, []string{"a", "b", "b"}, []string{"b", "c", "a"})
+	st.Expect(t, true, true, `Expected a permutation of [a b b] but actual value was [b c a]
+count of b: expected 2, actual 1
+count of c: expected 0, actual 1
+`)
+
+	st.Reset()
+	ExpectPermutation(&st, []int{1, 2}, []int{1, 2, 2})
+	st.Expect(t, true, true, `Expected a permutation of [1 2] but actual value was [1 2 2]
+count of 2: expected 1, actual 2
+`)
+}
+
+func TestExpectSubslice(t *testing.T) {
+	var st StubReporter
+	ExpectSubslice(&st, []int{1, 2, 3, 4}, []int{2, 3})
+	st.Expect(t, false, false, "")
+	ExpectSubslice(&st, []int{1, 2, 3, 4}, []int{1, 2, 3, 4})
+	st.Expect(t, false, false, "")
+	ExpectSubslice(&st, []int{1, 2, 3, 4}, []int{3, 4})
+	st.Expect(t, false, false, "")
+	ExpectSubslice(&st, nil, []int{})
+	st.Expect(t, false, false, "")
+	ExpectSubslice(&st, []int{1, 2, 1, 2, 3}, []int{1, 2, 3})
+	st.Expect(t, false, false, "")
+
+	ExpectSubslice(&st, []int{1, 2, 3, 4}, []int{1, 3})
+	st.Expect(t, true, true, "Expected [1 2 3 4] to contain [1 3]\n")
+
+	st.Reset()
+	ExpectSubslice(&st, []string{"a"}, []string{"a", "b"})
+	st.Expect(t, true, true, "Expected [a] to contain [a b]\n")
+}
+
+func TestExpectUnique(t *testing.T) {
+	var st StubReporter
+	ExpectUnique(&st, []int{})
+	st.Expect(t, false, false, "")
+	ExpectUnique(&st, []string{"a", "b", "c"})
+	st.Expect(t, false, false, "")
+
+	ExpectUnique(&st, []string{"a", "b", "c", "b", "a"})
+	st.Expect(t, true, true, "Duplicate value b at indices 1 and 3\n")
+
+	st.Reset()
+	ExpectUnique(NotFatal{&st}, []int{7, 7, 7})
+	st.Expect(t, true, false, "Duplicate value 7 at indices 0 and 1\n")
+}
+
+func TestExpectPrefixSlice(t *testing.T) {
+	var st StubReporter
+	ExpectPrefixSlice(&st, []int{1, 2}, []int{1, 2, 3})
+	st.Expect(t, false, false, "")
+	ExpectPrefixSlice(&st, nil, []int{1, 2, 3})
+	st.Expect(t, false, false, "")
+	ExpectPrefixSlice(&st, []int{1, 2, 3}, []int{1, 2, 3})
+	st.Expect(t, false, false, "")
+
+	ExpectPrefixSlice(&st, []int{1, 3}, []int{1, 2, 3})
+	st.Expect(t, true, true, "Expected prefix [1 3] but actual slice began [1 2]\n")
+
+	st.Reset()
+	ExpectPrefixSlice(&st, []string{"a", "b"}, []string{"a"})
+	st.Expect(t, true, true, "Expected prefix [a b] but actual slice began [a]\n")
+}
+
+func TestExpectSuffixSlice(t *testing.T) {
+	var st StubReporter
+	ExpectSuffixSlice(&st, []int{2, 3}, []int{1, 2, 3})
+	st.Expect(t, false, false, "")
+	ExpectSuffixSlice(&st, []int{}, nil)
+	st.Expect(t, false, false, "")
+
+	ExpectSuffixSlice(&st, []int{1, 3}, []int{1, 2, 3})
+	st.Expect(t, true, true, "Expected suffix [1 3] but actual slice ended [2 3]\n")
+
+	st.Reset()
+	ExpectSuffixSlice(&st, []int{0, 1, 2, 3}, []int{1, 2, 3})
+	st.Expect(t, true, true, "Expected suffix [0 1 2 3] but actual slice ended [1 2 3]\n")
+}
+
+func TestExpectMonotonic(t *testing.T) {
+	var st StubReporter
+	ExpectMonotonic(&st, []int{}, true)
+	st.Expect(t, false, false, "")
+	ExpectMonotonic(&st, []int{1, 2, 2, 5}, false)
+	st.Expect(t, false, false, "")
+	ExpectMonotonic(&st, []string{"a", "b", "c"}, true)
+	st.Expect(t, false, false, "")
+
+	ExpectMonotonic(&st, []int{1, 2, 2, 5}, true)
+	st.Expect(t, true, true, "Elements at indices 1 and 2 are equal: 2, 2\n")
+
+	st.Reset()
+	ExpectMonotonic(&st, []float64{1, 2.5, 2, 1}, false)
+	st.Expect(t, true, true, "Elements at indices 1 and 2 are decreasing: 2.5, 2\n")
+}
+
+func TestExpectStableSort(t *testing.T) {
+	type rec struct {
+		k    int
+		name string
+	}
+	key := func(r rec) int {
+		return r.k
+	}
+	original := []rec{{2, "a"}, {1, "b"}, {2, "c"}, {1, "d"}, {0, "e"}}
+
+	var st StubReporter
+	ExpectStableSort(&st, original, []rec{{0, "e"}, {1, "b"}, {1, "d"}, {2, "a"}, {2, "c"}}, key)
+	st.Expect(t, false, false, "")
+	ExpectStableSort(&st, nil, []rec{}, key)
+	st.Expect(t, false, false, "")
+
+	ExpectStableSort(&st, original, []rec{{0, "e"}, {1, "d"}, {1, "b"}, {2, "a"}, {2, "c"}}, key)
+	st.Expect(t, true, true, "Element at index 1 of sorted slice is {1 d}; expected {1 b}, from index 1 of original\n")
+
+	st.Reset()
+	ExpectStableSort(&st, original, []rec{{0, "e"}, {2, "a"}, {1, "b"}, {1, "d"}, {2, "c"}}, key)
+	st.Expect(t, true, true, "Elements at indices 1 and 2 of sorted slice are out of order: keys 2, 1\n")
+
+	st.Reset()
+	ExpectStableSort(&st, original, []rec{{0, "e"}, {0, "e"}, {1, "b"}, {1, "d"}, {2, "a"}}, key)
+	st.Expect(t, true, true, "Element at index 1 of sorted slice has key 0, but original has no more elements with that key\n")
+
+	st.Reset()
+	ExpectStableSort(&st, original, original[1:], key)
+	st.Expect(t, true, true, "Sorted slice has length 4 but original has length 5\n")
+}
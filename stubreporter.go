@@ -5,6 +5,7 @@ package gotest
 
 import (
 	"fmt"
+	"os"
 	"strings"
 )
 
@@ -14,8 +15,16 @@ import (
 // The methods save the results of calls to be queried later,
 // but do not do anything else.
 type StubReporter struct {
-	log            strings.Builder
-	failed, killed bool
+	log                     strings.Builder
+	failed, killed, skipped bool
+	cleanups                []func()
+	name, skipReason        string
+	counts                  stubCounts
+}
+
+// Type stubCounts holds the numbers of calls to various StubReporter methods.
+type stubCounts struct {
+	fails, logs, errors, fatals int
 }
 
 // Helper marks a function as a helper function.
@@ -25,6 +34,7 @@ func (sr *StubReporter) Helper() {}
 
 // Fail marks a test as failed.
 func (sr *StubReporter) Fail() {
+	sr.counts.fails++
 	sr.failed = true
 }
 
@@ -40,7 +50,8 @@ func (sr *StubReporter) Failed() bool {
 // Careful attention should be paid to this point when using StubReporter
 // to write test cases for helper functions.
 func (sr *StubReporter) FailNow() {
-	sr.Fail()
+	sr.counts.fails++
+	sr.failed = true
 	sr.killed = true
 }
 
@@ -55,6 +66,12 @@ func (sr *StubReporter) Killed() bool {
 // additional newline, creating a blank line in the output. This seems undesirable,
 // but it is what the testing package does, so we do the same.
 func (sr *StubReporter) Log(args ...any) {
+	sr.counts.logs++
+	sr.println(args...)
+}
+
+// Method println records text formatted as if by fmt.Println.
+func (sr *StubReporter) println(args ...any) {
 	_, e := fmt.Fprintln(&sr.log, args...)
 	if e != nil {
 		// Should be impossible
@@ -64,6 +81,12 @@ func (sr *StubReporter) Log(args ...any) {
 
 // Logf formats its arguments as if by fmt.Printf and records the resulting text.
 func (sr *StubReporter) Logf(format string, args ...any) {
+	sr.counts.logs++
+	sr.printf(format, args...)
+}
+
+// Method printf records text formatted as if by fmt.Printf, adding a final newline if needed.
+func (sr *StubReporter) printf(format string, args ...any) {
 	oldLen := sr.log.Len()
 	_, e := fmt.Fprintf(&sr.log, format, args...)
 	if e != nil {
@@ -80,28 +103,67 @@ func (sr *StubReporter) Logged() string {
 	return sr.log.String()
 }
 
-// Error calls Fail and Log.
+// LogLines returns the text recorded by Log and Logf, split into lines.
+//
+// The final newline does not produce an empty last element; if nothing was recorded,
+// LogLines returns nil. Blank lines, such as those Log produces when its last argument
+// ends with a newline, are returned as empty strings.
+func (sr *StubReporter) LogLines() []string {
+	return splitLines(sr.log.String())
+}
+
+// Error marks a test as failed and records text as Log does.
 func (sr *StubReporter) Error(args ...any) {
-	sr.Fail()
-	sr.Log(args...)
+	sr.counts.errors++
+	sr.failed = true
+	sr.println(args...)
 }
 
-// Errorf calls Fail and Logf.
+// Errorf marks a test as failed and records text as Logf does.
 func (sr *StubReporter) Errorf(format string, args ...any) {
-	sr.Fail()
-	sr.Logf(format, args...)
+	sr.counts.errors++
+	sr.failed = true
+	sr.printf(format, args...)
 }
 
-// Fatal calls FailNow and Log.
+// Fatal marks a test as failed and killed, as FailNow does, and records text as Log does.
 func (sr *StubReporter) Fatal(args ...any) {
-	sr.FailNow()
-	sr.Log(args...)
+	sr.counts.fatals++
+	sr.failed = true
+	sr.killed = true
+	sr.println(args...)
 }
 
-// Fatalf calls FailNow and Logf.
+// Fatalf marks a test as failed and killed, as FailNow does, and records text as Logf does.
 func (sr *StubReporter) Fatalf(format string, args ...any) {
-	sr.FailNow()
-	sr.Logf(format, args...)
+	sr.counts.fatals++
+	sr.failed = true
+	sr.killed = true
+	sr.printf(format, args...)
+}
+
+// FailCount returns the number of calls to Fail and FailNow.
+//
+// Calls to Error, Errorf, Fatal, and Fatalf are not included.
+func (sr *StubReporter) FailCount() int {
+	return sr.counts.fails
+}
+
+// LogCount returns the number of calls to Log and Logf.
+//
+// Calls to Error, Errorf, Fatal, Fatalf, Skip, and Skipf are not included.
+func (sr *StubReporter) LogCount() int {
+	return sr.counts.logs
+}
+
+// ErrorCount returns the number of calls to Error and Errorf.
+func (sr *StubReporter) ErrorCount() int {
+	return sr.counts.errors
+}
+
+// FatalCount returns the number of calls to Fatal and Fatalf.
+func (sr *StubReporter) FatalCount() int {
+	return sr.counts.fatals
 }
 
 // Expect verifies the status of the StubReporter.
@@ -136,9 +198,92 @@ func (sr *StubReporter) Expect(t Reporter, failed, killed bool, log string) {
 	}
 }
 
-// Reset returns a StubReporter to the initial state.
+// SkipNow marks a test as skipped.
+//
+// As with FailNow, the testing versions of SkipNow, Skip, and Skipf terminate the test case,
+// but the StubReporter versions return. SkipNow does not mark the test failed or killed.
+func (sr *StubReporter) SkipNow() {
+	sr.skipped = true
+}
+
+// Skipped returns whether SkipNow was called.
+func (sr *StubReporter) Skipped() bool {
+	return sr.skipped
+}
+
+// Skip records text as Log does, saves it as the skip reason, and calls SkipNow.
+func (sr *StubReporter) Skip(args ...any) {
+	sr.skipReason = strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+	sr.println(args...)
+	sr.SkipNow()
+}
+
+// Skipf records text as Logf does, saves it as the skip reason, and calls SkipNow.
+func (sr *StubReporter) Skipf(format string, args ...any) {
+	sr.skipReason = strings.TrimSuffix(fmt.Sprintf(format, args...), "\n")
+	sr.printf(format, args...)
+	sr.SkipNow()
+}
+
+// SkipReason returns the text passed to the most recent call of Skip or Skipf,
+// formatted without a final newline, or "" if neither has been called.
+//
+// SkipNow does not change the skip reason.
+func (sr *StubReporter) SkipReason() string {
+	return sr.skipReason
+}
+
+// Cleanup registers a function to be called by RunCleanup or Reset.
+func (sr *StubReporter) Cleanup(f func()) {
+	sr.cleanups = append(sr.cleanups, f)
+}
+
+// RunCleanup calls the functions registered by Cleanup, most recently registered first,
+// and forgets them.
+//
+// The testing package calls such functions when a test completes;
+// StubReporter only calls them when asked.
+func (sr *StubReporter) RunCleanup() {
+	for len(sr.cleanups) > 0 {
+		last := len(sr.cleanups) - 1
+		f := sr.cleanups[last]
+		sr.cleanups = sr.cleanups[:last]
+		f()
+	}
+}
+
+// TempDir creates a new temporary directory and returns its name.
+//
+// The directory and its contents are removed by RunCleanup or Reset.
+// If the directory cannot be created, TempDir panics.
+func (sr *StubReporter) TempDir() string {
+	dir, e := os.MkdirTemp("", "StubReporter")
+	if e != nil {
+		panic(e)
+	}
+	sr.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// Name returns the name set by SetName, or "" if SetName has not been called.
+func (sr *StubReporter) Name() string {
+	return sr.name
+}
+
+// SetName sets the name returned by Name.
+//
+// The name is not changed by Reset.
+func (sr *StubReporter) SetName(name string) {
+	sr.name = name
+}
+
+// Reset calls RunCleanup, then returns a StubReporter to the initial state.
 func (sr *StubReporter) Reset() {
+	sr.RunCleanup()
 	sr.log.Reset()
 	sr.failed = false
 	sr.killed = false
+	sr.skipped = false
+	sr.skipReason = ""
+	sr.counts = stubCounts{}
 }
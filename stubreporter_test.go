@@ -4,7 +4,11 @@
 package gotest
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -114,6 +118,48 @@ func TestStubFatalf(t *testing.T) {
 	sr.Expect(t, true, true, "boo\n")
 }
 
+func TestStubSkip(t *testing.T) {
+	var sr StubReporter
+	Expect(t, false, sr.Skipped())
+	sr.SkipNow()
+	Expect(t, true, sr.Skipped())
+	sr.Expect(t, false, false, "")
+
+	sr.Reset()
+	Expect(t, false, sr.Skipped())
+	sr.Skip("no", "network")
+	Expect(t, true, sr.Skipped())
+	sr.Expect(t, false, false, "no network\n")
+
+	sr.Reset()
+	sr.Skipf("needs %s", "root")
+	Expect(t, true, sr.Skipped())
+	sr.Expect(t, false, false, "needs root\n")
+
+	sr.Error("failed first")
+	sr.Skip()
+	Expect(t, true, sr.Skipped())
+	sr.Expect(t, true, false, "needs root\nfailed first\n\n")
+}
+
+func TestStubSkipReason(t *testing.T) {
+	var sr StubReporter
+	Expect(t, "", sr.SkipReason())
+
+	sr.Skip("no", "network", 4)
+	Expect(t, "no network 4", sr.SkipReason())
+
+	sr.Skipf("needs %s (uid %d)\n", "root", 0)
+	Expect(t, "needs root (uid 0)", sr.SkipReason())
+
+	sr.SkipNow()
+	Expect(t, "needs root (uid 0)", sr.SkipReason())
+
+	sr.Reset()
+	Expect(t, "", sr.SkipReason())
+	Expect(t, false, sr.Skipped())
+}
+
 func TestStubReset(t *testing.T) {
 	var sr StubReporter
 	sr.Expect(t, false, false, "")
@@ -125,6 +171,102 @@ func TestStubReset(t *testing.T) {
 	sr.Expect(t, false, false, "")
 }
 
+func TestStubCleanup(t *testing.T) {
+	var sr StubReporter
+	var order []int
+	sr.Cleanup(func() { order = append(order, 1) })
+	sr.Cleanup(func() { order = append(order, 2) })
+	sr.Cleanup(func() {
+		order = append(order, 3)
+		sr.Cleanup(func() { order = append(order, 4) })
+	})
+	Expect(t, 0, len(order))
+	sr.RunCleanup()
+	ExpectSlice(t, []int{3, 4, 2, 1}, order)
+	sr.RunCleanup()
+	ExpectSlice(t, []int{3, 4, 2, 1}, order)
+
+	order = nil
+	sr.Cleanup(func() { order = append(order, 5) })
+	sr.Log("x")
+	sr.Reset()
+	ExpectSlice(t, []int{5}, order)
+	sr.Expect(t, false, false, "")
+}
+
+func TestStubTempDir(t *testing.T) {
+	var sr StubReporter
+	dir1 := sr.TempDir()
+	dir2 := sr.TempDir()
+	Require(t, dir1 != dir2)
+	Require(t, os.WriteFile(filepath.Join(dir1, "file"), []byte("data"), 0o666) == nil)
+	info, e := os.Stat(dir2)
+	Require(t, e == nil)
+	Require(t, info.IsDir())
+
+	sr.Reset()
+	_, e = os.Stat(dir1)
+	Require(t, errors.Is(e, fs.ErrNotExist))
+	_, e = os.Stat(dir2)
+	Require(t, errors.Is(e, fs.ErrNotExist))
+	sr.Expect(t, false, false, "")
+}
+
+func TestStubName(t *testing.T) {
+	var sr StubReporter
+	Expect(t, "", sr.Name())
+	sr.SetName("TestSomething/case_1")
+	Expect(t, "TestSomething/case_1", sr.Name())
+	sr.Reset()
+	Expect(t, "TestSomething/case_1", sr.Name())
+}
+
+func TestStubLogLines(t *testing.T) {
+	var sr StubReporter
+	Require(t, sr.LogLines() == nil)
+
+	sr.Log("one")
+	ExpectSlice(t, []string{"one"}, sr.LogLines())
+
+	sr.Log("two\n")
+	sr.Logf("three\nfour")
+	sr.Log()
+	sr.Error("five")
+	ExpectSlice(t, []string{"one", "two", "", "three", "four", "", "five"}, sr.LogLines())
+
+	sr.Reset()
+	Require(t, sr.LogLines() == nil)
+}
+
+func TestStubCounts(t *testing.T) {
+	var sr StubReporter
+	check := func(fails, logs, errors, fatals int) {
+		t.Helper()
+		Expect(t, fails, sr.FailCount())
+		Expect(t, logs, sr.LogCount())
+		Expect(t, errors, sr.ErrorCount())
+		Expect(t, fatals, sr.FatalCount())
+	}
+	check(0, 0, 0, 0)
+
+	sr.Error("a")
+	sr.Log("b")
+	sr.Errorf("%s", "c")
+	check(0, 1, 2, 0)
+
+	sr.Fatal("d")
+	sr.Logf("e")
+	sr.Fail()
+	sr.Fatalf("f")
+	sr.FailNow()
+	sr.Skip("g")
+	check(2, 2, 2, 2)
+	sr.Expect(t, true, true, "a\nb\nc\nd\ne\nf\ng\n")
+
+	sr.Reset()
+	check(0, 0, 0, 0)
+}
+
 func TestStubMessages(t *testing.T) {
 	var sr, x StubReporter
 	sr.Expect(&x, true, true, "oops\n")